@@ -1,8 +1,10 @@
 package libcmd
 
 import (
+	"context"
 	"io"
 	"os"
+	"reflect"
 )
 
 // CmdCallback is a routine that runs when new command is configured.
@@ -28,6 +30,29 @@ type MatchCallback func(cmd *Cmd)
 // available as a return to the Run or RunArgs on the App instance.
 type RunCallback func(cmd *Cmd) error
 
+// RunContextCallback works like RunCallback, also receiving the context of
+// the app (see App.RunContext). Long-running commands should stop when the
+// context is cancelled.
+type RunContextCallback func(ctx context.Context, cmd *Cmd) error
+
+// OptCallback is a callback that runs when an argument is set in the command
+// line, right after it's value is parsed (the remaining arguments are not
+// parsed yet). To end the processing successfully, skipping the remaining
+// arguments, the subcommands and the Run callback, return ErrStop. Any other
+// error aborts the parsing, being returned by Parse or ParseArgs.
+type OptCallback func(cmd *Cmd) error
+
+// CompleteCallback is a callback that returns the candidates to complete an
+// argument value or an operand, along with a directive to the shell (use 0 for
+// the default behavior). The prefix is the partial word typed by the user; the
+// candidates that do not start with it are discarded, so the callback may
+// return every possible value.
+//
+// The cmd parameter is the command being completed, with the arguments
+// already typed by the user parsed, so previous values can be used to compute
+// the candidates.
+type CompleteCallback func(cmd *Cmd, prefix string) ([]Completion, CompletionDirective)
+
 // ErrCallback is a callback that executes when the parser encounters an error.
 // If you wish to recover (or ignore) the error, return a nil value to force the parser
 // to continue it's normal process.
@@ -39,11 +64,29 @@ type HelpCallback func(cmd *Cmd, out io.Writer)
 // Options defines the configuration options of the main
 // App instance. The zero value of this struct reflect the default
 // set of options.
+//
+// Each command has its own set of options, and any field left with its
+// zero value is inherited from the nearest parent command that sets it.
+// To use the zero value on a subcommand (like turning off an option enabled
+// by one of it's parents), mark the field with Override. Use EffectiveOptions
+// to query the options that are actually in use by a given command.
 type Options struct {
 	// When true, the automatic creation of help flags will
 	// be suppressed.
 	SuppressHelpFlag bool
 
+	// When true, adds a 'help' command to the app, that prints the help
+	// text of the command path passed as operands (e. g. 'app help sub')
+	HelpCommand bool
+
+	// When true, adds a '--help-all' flag to the app, that prints
+	// the help text of every command (see Cmd.PrintHelpAll)
+	HelpAllFlag bool
+
+	// When true, adds a '--help-tree' flag to the app, that prints
+	// the tree of commands (see Cmd.PrintTree)
+	HelpTreeFlag bool
+
 	// When true, do not print the help automatically when a
 	// help flag is set
 	SupressPrintHelpWhenSet bool
@@ -62,6 +105,135 @@ type Options struct {
 
 	// Function that overrides the auto-generated help text.
 	OnHelp HelpCallback
+
+	// The width (in columns) of the help text. Long lines are wrapped to
+	// fit this width. When it is zero, the width is taken from the COLUMNS
+	// environment variable, falling back to 80 columns
+	HelpWidth int
+
+	// When true, the options are shown on the help text in the same
+	// order they were declared, instead of being sorted by name
+	KeepOptionOrder bool
+
+	// The order used to show the command categories on the help text.
+	// Categories not listed here are shown after the listed ones, sorted
+	// by name. Commands without a category are always shown first.
+	CategoryOrder []string
+
+	// The current version of the application. When set, the use of
+	// deprecated arguments and commands that were removed in this
+	// version (or before) returns an error
+	Version string
+
+	// When true, adds a '--version' flag to the app, that prints the
+	// version text (see App.PrintVersion)
+	VersionFlag bool
+
+	// When true, adds a 'version' command to the app, that prints the
+	// version text (see App.PrintVersion)
+	VersionCommand bool
+
+	// When true, the version text includes the module version and
+	// VCS information (revision, date and dirty flag) embedded in the
+	// executable by the Go toolchain. The VCS information requires
+	// Go 1.18 or newer
+	VersionBuildInfo bool
+
+	// When true, adds a 'completion' command to the app, that prints
+	// the completion script of the shell passed as operand
+	// (see App.PrintCompletion)
+	CompletionCommand bool
+
+	// When true, the app answers to the hidden '__complete' command, that
+	// prints the completion candidates of a partial command line, and the
+	// completion scripts (see App.PrintCompletion) call it to complete
+	// the command line, using the completion callbacks
+	DynamicCompletion bool
+
+	// When true, adds a hidden '--spec' flag to the app, that prints
+	// the description of the command-line interface as JSON
+	// (see App.WriteSpec)
+	SpecFlag bool
+
+	// When set, redirect the warnings (like the use of deprecated
+	// names) to the specified writer.
+	// When it is nil, the warnings will be printed to Stderr
+	WarningOutput io.Writer
+
+	// When true, a parsing error (like an unknown argument or a missing
+	// operand) is printed to ErrorOutput, followed by the usage line of the
+	// failing command and a hint on how to get more help. The error is
+	// still returned by Parse and ParseArgs
+	PrintUsageOnError bool
+
+	// When set, redirect the errors printed due to PrintUsageOnError to
+	// the specified writer.
+	// When it is nil, the errors will be printed to Stderr
+	ErrorOutput io.Writer
+
+	// When true, the context of the app (see App.RunContext) is cancelled
+	// when the process receives SIGINT or SIGTERM, allowing the running
+	// command to stop cleanly. A second signal exits the process immediately
+	CancelOnSignal bool
+
+	// the fields set with Override
+	overridden map[string]bool
+}
+
+// Override marks the named fields (like "StrictOperands" or "HelpOutput") as
+// set on this command, so their values are used even when they are zero,
+// instead of being inherited from the parent commands:
+//
+//	sub.Options.StrictOperands = false
+//	sub.Options.HelpOutput = nil
+//	sub.Options.Override("StrictOperands", "HelpOutput")
+//
+// Unknown field names cause a panic.
+func (opts *Options) Override(fields ...string) {
+	overridden := make(map[string]bool, len(opts.overridden)+len(fields))
+	for name := range opts.overridden {
+		overridden[name] = true
+	}
+
+	for _, name := range fields {
+		if field, ok := reflect.TypeOf(*opts).FieldByName(name); !ok || field.PkgPath != "" {
+			panic("unknown option: " + name)
+		}
+
+		overridden[name] = true
+	}
+
+	opts.overridden = overridden
+}
+
+// merge returns a copy of opts where every zero-valued field that
+// was not overridden is replaced by the same field of parent.
+func (opts Options) merge(parent Options) Options {
+	merged := reflect.ValueOf(&opts).Elem()
+	inherited := reflect.ValueOf(parent)
+
+	for i := 0; i < merged.NumField(); i++ {
+		field, info := merged.Field(i), merged.Type().Field(i)
+		if info.PkgPath != "" || opts.overridden[info.Name] {
+			continue
+		}
+
+		if isZeroValue(field) {
+			field.Set(inherited.Field(i))
+		}
+	}
+
+	return opts
+}
+
+// checks if the value is the zero value of it's type
+func isZeroValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Func, reflect.Slice, reflect.Map, reflect.Ptr, reflect.Interface, reflect.Chan:
+		return value.IsNil()
+	}
+
+	return value.Interface() == reflect.Zero(value.Type()).Interface()
 }
 
 // App defines the main application parser.
@@ -93,5 +265,5 @@ func (app *App) Parse() error {
 // ParseArgs behave like parse, but instead of looking to the command-line
 // arguments, it takes an array of arguments as parameters.
 func (app *App) ParseArgs(args []string) error {
-	return app.doRun(args)
+	return app.RunContext(context.Background(), args)
 }
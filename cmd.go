@@ -1,8 +1,17 @@
 package libcmd
 
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+)
+
 type operand struct {
 	name     string
 	modifier string
+	complete CompleteCallback
+	choices  []string
 }
 
 // Cmd defines a (sub)command of the application.
@@ -24,7 +33,26 @@ type Cmd struct {
 	// Set this value to "-" to omit the usage line
 	Usage string
 
-	// Options for this command
+	// Alternative names that can also be used to invoke the command
+	Aliases []string
+
+	// When true, the command still works, but is not shown on the
+	// list of commands of the help text
+	Hidden bool
+
+	// When set, marks the command as deprecated, printing a
+	// warning when it is used
+	Deprecated *Deprecation
+
+	// The category of the command. Commands of the same category
+	// are shown together on the help text of the parent command
+	Category string
+
+	// Usage examples, shown on the help text
+	Examples []Example
+
+	// Options for this command. Unset (zero) fields are
+	// inherited from the parent command.
 	Options Options
 
 	args        []string
@@ -34,11 +62,16 @@ type Cmd struct {
 	callback    CmdCallback
 	match       MatchCallback
 	run         RunCallback
+	runContext  RunContextCallback
 	errHandler  ErrCallback
 	breadcrumbs string
 	commands    map[string]*Cmd
 	parentCmd   *Cmd
 	operands    []operand
+	configured  bool
+	auto        bool
+	topics      []helpTopic
+	ctx         context.Context
 }
 
 func newCmd() *Cmd {
@@ -56,9 +89,24 @@ func newCmd() *Cmd {
 // the caller the opportunity to configure the newly created command, by
 // defining new flags, subcommands, or specifying what to run when
 // the command is activated.
-func (cmd *Cmd) Command(name, brief string, callback CmdCallback) {
+//
+// The new command is returned, so the caller can set fields that must be
+// known before the command is invoked, like Aliases or Hidden. The returned
+// value is nil when the name is empty. If the name is already used by another
+// subcommand (as name or alias), this routine panics.
+//
+// The callback is not executed right away, but only once, when the command
+// is first needed: when it is selected by the parser, or when the routines
+// that walk the subcommands (like PrintHelpAll, PrintCompletion, Spec or
+// CheckExamples) reach it. Because of that, it should only configure the
+// command, and leave the actual work to Run.
+func (cmd *Cmd) Command(name, brief string, callback CmdCallback) *Cmd {
 	if name == "" {
-		return
+		return nil
+	}
+
+	if cmd.findCommand(name) != nil {
+		panic("duplicated command name: " + name)
 	}
 
 	c := newCmd()
@@ -69,6 +117,54 @@ func (cmd *Cmd) Command(name, brief string, callback CmdCallback) {
 	c.parentCmd = cmd
 
 	cmd.commands[c.Name] = c
+	return c
+}
+
+// Parent returns the parent command, or nil if
+// this is the main app.
+func (cmd *Cmd) Parent() *Cmd {
+	return cmd.parentCmd
+}
+
+// the full path of the command, like 'app sub'
+func (cmd *Cmd) path() string {
+	return strings.TrimSpace(cmd.breadcrumbs + " " + cmd.Name)
+}
+
+// runs the command callback, making sure that it
+// runs only once
+func (cmd *Cmd) configure() {
+	if cmd.configured {
+		return
+	}
+
+	cmd.configured = true
+
+	if cmd.callback != nil {
+		cmd.callback(cmd)
+	}
+}
+
+// find a subcommand by name or alias. Since the aliases can be set
+// after the command is defined, an alias used by more than one command
+// (or equal to the name of another command) panics here
+func (cmd *Cmd) findCommand(name string) *Cmd {
+	names := make(map[string]*Cmd, len(cmd.commands))
+	for _, c := range cmd.commands {
+		names[c.Name] = c
+	}
+
+	for _, c := range cmd.commands {
+		for _, alias := range c.Aliases {
+			if other, ok := names[alias]; ok && other != c {
+				panic("duplicated command name: " + alias)
+			}
+
+			names[alias] = c
+		}
+	}
+
+	return names[name]
 }
 
 // Match registers a callback to run when this command matches.
@@ -81,6 +177,14 @@ func (cmd *Cmd) Match(callback MatchCallback) {
 // (see Match) and no more subcommands were invoked.
 func (cmd *Cmd) Run(callback RunCallback) {
 	cmd.run = callback
+	cmd.runContext = nil
+}
+
+// RunWithContext registers a callback to run when this command is matched,
+// like Run, that also receives the context of the app (see App.RunContext).
+func (cmd *Cmd) RunWithContext(callback RunContextCallback) {
+	cmd.runContext = callback
+	cmd.run = nil
 }
 
 // Err registers a handler to be run when the parsing fails.
@@ -90,20 +194,24 @@ func (cmd *Cmd) Err(handler ErrCallback) {
 
 // CommandMatch is a shortcut to Command() followed by Match() on the
 // provided command.
-func (cmd *Cmd) CommandMatch(name, brief string, callback MatchCallback) {
-	cmd.Command(name, brief, nil)
-	if c, ok := cmd.commands[name]; ok {
+func (cmd *Cmd) CommandMatch(name, brief string, callback MatchCallback) *Cmd {
+	c := cmd.Command(name, brief, nil)
+	if c != nil {
 		c.Match(callback)
 	}
+
+	return c
 }
 
 // CommandRun is a shortcut to Command() followed by Run() on the
 // provided command.
-func (cmd *Cmd) CommandRun(name, brief string, callback RunCallback) {
-	cmd.Command(name, brief, nil)
-	if c, ok := cmd.commands[name]; ok {
+func (cmd *Cmd) CommandRun(name, brief string, callback RunCallback) *Cmd {
+	c := cmd.Command(name, brief, nil)
+	if c != nil {
 		c.Run(callback)
 	}
+
+	return c
 }
 
 // AddOperand documents an expected operand.
@@ -118,6 +226,17 @@ func (cmd *Cmd) AddOperand(name string, modifer string) {
 	cmd.operands = append(cmd.operands, operand{name: name, modifier: modifer})
 }
 
+// CompleteOperand registers a callback that returns the candidate values
+// of the named operand, used by the dynamic completion (see
+// Options.DynamicCompletion). The operand must be added with AddOperand first.
+func (cmd *Cmd) CompleteOperand(name string, callback CompleteCallback) {
+	for i := range cmd.operands {
+		if cmd.operands[i].name == name {
+			cmd.operands[i].complete = callback
+		}
+	}
+}
+
 // Operand returns the value of the named operand, if any.
 // When specified using AddOperand, each unparsed arg is considered an operand and it's
 // value is fetched - but not consumed -  from the Args() method.
@@ -134,13 +253,86 @@ func (cmd *Cmd) Operand(name string) string {
 	return ""
 }
 
+// EffectiveOptions returns the options in use by this command, that is, the
+// command's own Options with every unset field inherited from the nearest
+// parent command that sets it.
+func (cmd *Cmd) EffectiveOptions() Options {
+	if cmd.parentCmd == nil {
+		return cmd.Options
+	}
+
+	return cmd.Options.merge(cmd.parentCmd.EffectiveOptions())
+}
+
+// the writer used to print warnings
+func (cmd *Cmd) warningOutput() io.Writer {
+	if output := cmd.EffectiveOptions().WarningOutput; output != nil {
+		return output
+	}
+
+	return os.Stderr
+}
+
 func (cmd *Cmd) setupHelp() {
+	options := cmd.EffectiveOptions()
+
+	// automatic commands and flags, only on the main app
+	if cmd.parentCmd == nil {
+		if options.HelpCommand && cmd.findCommand("help") == nil {
+			cmd.setupHelpCommand()
+			cmd.commands["help"].auto = true
+		}
+
+		if options.VersionCommand && cmd.findCommand("version") == nil {
+			cmd.setupVersionCommand()
+			cmd.commands["version"].auto = true
+		}
+
+		if options.CompletionCommand && cmd.findCommand("completion") == nil {
+			cmd.setupCompletionCommand()
+			cmd.commands["completion"].auto = true
+		}
+
+		if options.SpecFlag && cmd.longopt["--spec"] == nil {
+			cmd.setupSpecFlag()
+			cmd.optentries[len(cmd.optentries)-1].auto = true
+		}
+
+		if options.VersionFlag && cmd.longopt["--version"] == nil {
+			cmd.actionFlag("version", 0, "Show the version information.", cmd.printVersion)
+		}
+
+		if options.HelpAllFlag && cmd.longopt["--help-all"] == nil {
+			cmd.actionFlag("help-all", 0, "Show the help of every command.", cmd.PrintHelpAll)
+		}
+
+		if options.HelpTreeFlag && cmd.longopt["--help-tree"] == nil {
+			cmd.actionFlag("help-tree", 0, "Show the tree of commands.", cmd.PrintTree)
+		}
+	}
+
 	// no automatic '-h' flag
-	if cmd.Options.SuppressHelpFlag {
+	if options.SuppressHelpFlag {
 		return
 	}
 
 	if (len(cmd.optentries) > 0 || len(cmd.commands) > 0 || len(cmd.operands) > 0) && cmd.shortopt["-h"] == nil {
 		cmd.Bool("help", 'h', false, "Show this help message.")
+		cmd.optentries[len(cmd.optentries)-1].auto = true
+	}
+}
+
+// defines a flag that, when set, prints something to the
+// help output and stops the processing
+func (cmd *Cmd) actionFlag(long string, short rune, help string, action func(io.Writer)) {
+	target := cmd.Bool(long, short, false, help)
+	cmd.optentries[len(cmd.optentries)-1].auto = true
+	cmd.Opt(long).OnSet = func(*Cmd) error {
+		if !*target {
+			return nil
+		}
+
+		action(cmd.helpOutput())
+		return ErrStop
 	}
 }
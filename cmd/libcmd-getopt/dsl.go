@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+// a command being parsed, with the indentation of it's line
+type dslCommand struct {
+	spec   *libcmd.SpecCommand
+	indent int
+}
+
+// parses a spec written in the line-based format (see the package docs);
+// the result is validated later, by libcmd.NewAppFromSpec
+func parseDSL(reader io.Reader) (*libcmd.Spec, error) {
+	spec := &libcmd.Spec{Schema: libcmd.SpecSchema}
+
+	// the commands that can still receive items, from the outermost
+	var stack []dslCommand
+
+	scanner := bufio.NewScanner(reader)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		text := strings.TrimLeft(line, " \t")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		indent := len(line) - len(text)
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("line %d: %s", lineNo, fmt.Sprintf(format, args...))
+		}
+
+		var help string
+		if i := strings.Index(text, ": "); i >= 0 {
+			text, help = text[:i], strings.TrimSpace(text[i+2:])
+		}
+		text = strings.TrimSuffix(text, ":")
+
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return nil, fail("expected a keyword and a name")
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		if fields[0] == "app" {
+			if len(stack) > 0 || spec.App.Name != "" {
+				return nil, fail("the app must be declared once, on the first line")
+			}
+
+			if len(fields) > 2 {
+				return nil, fail("unexpected '%s'", fields[2])
+			}
+
+			spec.App.Name = fields[1]
+			spec.App.Brief = help
+			stack = append(stack, dslCommand{spec: &spec.App, indent: indent})
+			continue
+		}
+
+		if len(stack) == 0 {
+			return nil, fail("'%s' outside of the app", fields[0])
+		}
+
+		parent := stack[len(stack)-1].spec
+
+		switch fields[0] {
+		case "command":
+			if len(fields) > 2 {
+				return nil, fail("unexpected '%s'", fields[2])
+			}
+
+			names := strings.Split(fields[1], ",")
+			parent.Commands = append(parent.Commands, libcmd.SpecCommand{Name: names[0], Aliases: names[1:], Brief: help})
+			stack = append(stack, dslCommand{spec: &parent.Commands[len(parent.Commands)-1], indent: indent})
+
+		case "option":
+			if len(fields) > 3 {
+				return nil, fail("unexpected '%s'", fields[3])
+			}
+
+			opt := libcmd.SpecOption{Type: "bool", Help: help}
+			for _, name := range strings.Split(fields[1], ",") {
+				switch {
+				case opt.Long == "" && utf8.RuneCountInString(name) > 1:
+					opt.Long = name
+				case opt.Short == "" && utf8.RuneCountInString(name) == 1:
+					opt.Short = name
+				default:
+					opt.Aliases = append(opt.Aliases, name)
+				}
+			}
+
+			if len(fields) > 2 {
+				typ := fields[2]
+				if i := strings.Index(typ, "="); i >= 0 {
+					typ, opt.Default = typ[:i], typ[i+1:]
+				}
+
+				if strings.HasPrefix(typ, "choice(") && strings.HasSuffix(typ, ")") {
+					typ, opt.Choices = "choice", strings.Split(typ[len("choice("):len(typ)-1], "|")
+				}
+
+				opt.Type = typ
+			}
+
+			parent.Options = append(parent.Options, opt)
+
+		case "operand":
+			if len(fields) > 2 {
+				return nil, fail("unexpected '%s'", fields[2])
+			}
+
+			op := libcmd.SpecOperand{Name: fields[1]}
+			if last := op.Name[len(op.Name)-1:]; last == "?" || last == "*" {
+				op.Name, op.Modifier = op.Name[:len(op.Name)-1], last
+			}
+
+			parent.Operands = append(parent.Operands, op)
+
+		default:
+			return nil, fail("unknown keyword '%s'", fields[0])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if spec.App.Name == "" {
+		return nil, fmt.Errorf("missing the app declaration")
+	}
+
+	return spec, nil
+}
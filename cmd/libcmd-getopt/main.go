@@ -0,0 +1,381 @@
+// Command libcmd-getopt brings the libcmd parser to shell scripts. It reads
+// the command-line spec of the script (as JSON, written by App.WriteSpec, or
+// in a simple line-based format), parses the arguments of the script and
+// prints shell code to be evaluated by it:
+//
+//	eval "$(libcmd-getopt --dsl cli.txt -- "$@")"
+//
+// On success, the output assigns one variable per argument of the invoked
+// command (and of it's parents), named after the argument, plus one per
+// operand; the invoked command path is assigned to 'command' and the
+// operands are set as the positional parameters ("$@"). The variables must
+// be unique for every command path and, without a prefix, can't overwrite
+// the common shell variables (like PATH). When the help (or the version) is
+// requested, the output prints it and exits the script with status 0.
+// On parse errors the message is printed to stderr and the output exits
+// the script with status 1. Errors on the spec itself exit with status 2.
+//
+// The line-based format declares one item per line, nested by indentation.
+// Everything after ': ' is the help text and '#' starts a comment line:
+//
+//	app backup: Backs up a directory.
+//	  option verbose,v: Print every copied file.
+//	  option level,l choice(fast|best)=fast: The compression level.
+//	  option jobs,j int=2: The number of parallel jobs.
+//	  operand src
+//	  operand dest?
+//	  command restore,r: Restores a backup.
+//	    option force,f bool: Overwrite the existing files.
+//
+// The option names are separated by commas: the first long name, the first
+// single-character name and the remaining names are the aliases. The type
+// is any of the types of the JSON spec ('bool' if omitted), with an optional
+// default value after '='. The command names work the same way.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// runs the tool, returning the exit status; the arguments of
+// the script are the ones after '--'
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var scriptArgs []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, scriptArgs = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	app := libcmd.NewApp("libcmd-getopt", "Parses the arguments of shell scripts.")
+	app.Usage = "libcmd-getopt (--spec FILE | --dsl FILE) [--prefix PREFIX] -- [ARGS...]"
+	app.Long = "Parses the arguments of a shell script according to it's command-line spec, printing shell code that sets the parsed values. Use '-' as the file name to read the spec from stdin."
+	var help strings.Builder
+	app.Options.StrictOperands = true
+	app.Options.PrintUsageOnError = true
+	app.Options.HelpOutput = &help
+	app.Options.ErrorOutput = stderr
+
+	jsonFile := app.String("spec", 's', "", "Read the spec as JSON, as written by the '--spec' flag of libcmd apps.", "FILE")
+	dslFile := app.String("dsl", 'd', "", "Read the spec in the line-based format.", "FILE")
+	prefix := app.String("prefix", 'p', "", "Prefix the names of the variables.", "PREFIX")
+
+	status := 0
+	ran := false
+	app.Run(func(cmd *libcmd.Cmd) error {
+		ran = true
+
+		var spec *libcmd.Spec
+		var err error
+
+		switch {
+		case (*jsonFile == "") == (*dslFile == ""):
+			err = fmt.Errorf("exactly one of '--spec' or '--dsl' must be used")
+		case *jsonFile != "":
+			spec, err = readSpec(*jsonFile, stdin, libcmd.ReadSpec)
+		default:
+			spec, err = readSpec(*dslFile, stdin, parseDSL)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		status, err = parseScriptArgs(spec, scriptArgs, *prefix, stdout, stderr)
+		return err
+	})
+
+	if err := app.ParseArgs(args); err != nil {
+		if !libcmd.IsParserErr(err) {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+		}
+
+		fmt.Fprintln(stdout, "exit 2")
+		return 2
+	}
+
+	// the help of the tool itself was printed
+	if !ran {
+		printHelp(help.String(), stdout)
+	}
+
+	return status
+}
+
+// prints the shell code that shows the help text and exits the script
+func printHelp(help string, stdout io.Writer) {
+	fmt.Fprintf(stdout, "printf '%%s' %s\nexit 0\n", shellQuote(help))
+}
+
+func readSpec(name string, stdin io.Reader, parse func(io.Reader) (*libcmd.Spec, error)) (*libcmd.Spec, error) {
+	if name == "-" {
+		return parse(stdin)
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	spec, err := parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	return spec, nil
+}
+
+// parses the arguments of the script, printing the shell code
+// and returning the exit status of the script
+func parseScriptArgs(spec *libcmd.Spec, args []string, prefix string, stdout, stderr io.Writer) (int, error) {
+	// every command path gets a run callback, to find the invoked one
+	var leaf *libcmd.Cmd
+	runs := make(map[string]libcmd.RunCallback)
+	addRuns(&spec.App, spec.App.Name, func(cmd *libcmd.Cmd) error {
+		leaf = cmd
+		return nil
+	}, runs)
+
+	app, err := libcmd.NewAppFromSpec(spec, runs)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := checkVarNames(&spec.App, spec.App.Name, prefix, map[string]string{varName(prefix, "command"): "command path"}); err != nil {
+		return 0, err
+	}
+
+	var help strings.Builder
+	app.Options.StrictOperands = true
+	app.Options.PrintUsageOnError = true
+	app.Options.HelpOutput = &help
+	app.Options.ErrorOutput = stderr
+
+	if err := app.ParseArgs(args); err != nil {
+		if !libcmd.IsParserErr(err) {
+			return 0, err
+		}
+
+		fmt.Fprintln(stdout, "exit 1")
+		return 1, nil
+	}
+
+	// no command ran, so the help (or the version) was printed
+	if leaf == nil {
+		printHelp(help.String(), stdout)
+		return 0, nil
+	}
+
+	var path []*libcmd.Cmd
+	for cmd := leaf; cmd != nil; cmd = cmd.Parent() {
+		path = append([]*libcmd.Cmd{cmd}, path...)
+	}
+
+	var names []string
+	specCmd := &spec.App
+	for i, cmd := range path {
+		if i > 0 {
+			specCmd = findCommand(specCmd.Commands, cmd.Name)
+			names = append(names, specCmd.Name)
+		}
+
+		for _, opt := range scriptOptions(specCmd) {
+			name := optName(opt)
+			fmt.Fprintf(stdout, "%s=%s\n", varName(prefix, name), shellQuote(optValue(cmd, name, opt.Type)))
+		}
+	}
+
+	for _, op := range specCmd.Operands {
+		if op.Modifier != "*" {
+			fmt.Fprintf(stdout, "%s=%s\n", varName(prefix, op.Name), shellQuote(leaf.Operand(op.Name)))
+		}
+	}
+
+	fmt.Fprintf(stdout, "%s=%s\n", varName(prefix, "command"), shellQuote(strings.Join(names, " ")))
+
+	fmt.Fprint(stdout, "set --")
+	for _, arg := range leaf.Args() {
+		fmt.Fprintf(stdout, " %s", shellQuote(arg))
+	}
+	fmt.Fprintln(stdout)
+
+	return 0, nil
+}
+
+// the commands created by the app itself (like 'help') run on their own
+func addRuns(spec *libcmd.SpecCommand, path string, run libcmd.RunCallback, runs map[string]libcmd.RunCallback) {
+	runs[path] = run
+
+	for i := range spec.Commands {
+		if !spec.Commands[i].Auto {
+			addRuns(&spec.Commands[i], path+" "+spec.Commands[i].Name, run, runs)
+		}
+	}
+}
+
+// the options that are assigned to variables; the automatic ones
+// (like '--help') are handled by the app itself
+func scriptOptions(spec *libcmd.SpecCommand) []libcmd.SpecOption {
+	var options []libcmd.SpecOption
+	for _, opt := range spec.Options {
+		if !opt.Auto && !(opt.Long == "help" && opt.Type == "bool") {
+			options = append(options, opt)
+		}
+	}
+
+	return options
+}
+
+// the name used to get the value of an option and to name it's variable
+func optName(opt libcmd.SpecOption) string {
+	if opt.Long != "" {
+		return opt.Long
+	}
+
+	return opt.Short
+}
+
+// the variables that the scripts usually depend on, when not prefixed
+var shellVars = map[string]bool{
+	"CDPATH": true, "ENV": true, "HOME": true, "IFS": true, "LANG": true,
+	"OLDPWD": true, "OPTARG": true, "OPTIND": true, "PATH": true, "PPID": true,
+	"PS1": true, "PS2": true, "PS4": true, "PWD": true, "SHELL": true,
+}
+
+// checks that every variable set when a command path is invoked comes from a
+// single item, so no value is overwritten; the options of the parent commands
+// are set too, so they are passed to the subcommands
+func checkVarNames(spec *libcmd.SpecCommand, path, prefix string, inherited map[string]string) error {
+	names := make(map[string]string)
+	for name, item := range inherited {
+		names[name] = item
+	}
+
+	add := func(names map[string]string, name, item string) error {
+		name = varName(prefix, name)
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("the %s and the %s of '%s' use the same variable '%s'", other, item, path, name)
+		}
+
+		if shellVars[name] {
+			return fmt.Errorf("the %s of '%s' overwrites the shell variable '%s' (use '--prefix')", item, path, name)
+		}
+
+		names[name] = item
+		return nil
+	}
+
+	for _, opt := range scriptOptions(spec) {
+		if err := add(names, optName(opt), fmt.Sprintf("option '%s'", optName(opt))); err != nil {
+			return err
+		}
+	}
+
+	operands := make(map[string]string)
+	for name, item := range names {
+		operands[name] = item
+	}
+
+	for _, op := range spec.Operands {
+		if op.Modifier != "*" {
+			if err := add(operands, op.Name, fmt.Sprintf("operand '%s'", op.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := range spec.Commands {
+		c := &spec.Commands[i]
+		if c.Auto {
+			continue
+		}
+
+		if err := checkVarNames(c, path+" "+c.Name, prefix, names); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findCommand(commands []libcmd.SpecCommand, name string) *libcmd.SpecCommand {
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i]
+		}
+	}
+
+	return nil
+}
+
+// the value of an argument, using the getter of it's type
+func optValue(cmd *libcmd.Cmd, name, typ string) string {
+	switch typ {
+	case "bool":
+		return fmt.Sprint(*cmd.GetBool(name))
+	case "string":
+		return *cmd.GetString(name)
+	case "choice":
+		return *cmd.GetChoice(name)
+	case "int":
+		return fmt.Sprint(*cmd.GetInt(name))
+	case "int8":
+		return fmt.Sprint(*cmd.GetInt8(name))
+	case "int16":
+		return fmt.Sprint(*cmd.GetInt16(name))
+	case "int32":
+		return fmt.Sprint(*cmd.GetInt32(name))
+	case "int64":
+		return fmt.Sprint(*cmd.GetInt64(name))
+	case "uint":
+		return fmt.Sprint(*cmd.GetUint(name))
+	case "uint8":
+		return fmt.Sprint(*cmd.GetUint8(name))
+	case "uint16":
+		return fmt.Sprint(*cmd.GetUint16(name))
+	case "uint32":
+		return fmt.Sprint(*cmd.GetUint32(name))
+	case "uint64":
+		return fmt.Sprint(*cmd.GetUint64(name))
+	case "float32":
+		return fmt.Sprint(*cmd.GetFloat32(name))
+	case "float64":
+		return fmt.Sprint(*cmd.GetFloat64(name))
+	}
+
+	// the custom arguments are rejected when the app is built
+	panic("unsupported type: " + typ)
+}
+
+// the name of the variable, with every invalid character replaced by '_'
+func varName(prefix, name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+
+		return '_'
+	}, prefix+name)
+
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// quotes a value between single quotes
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
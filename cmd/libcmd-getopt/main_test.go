@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+const testDSL = `# test script
+app backup: Backs up a directory.
+  option verbose,v: Print every copied file.
+  option level,l choice(fast|best)=fast: The compression level.
+  option jobs,j int=2
+  operand src
+  operand dest?
+  command restore,r: Restores a backup.
+    option force,f bool: Overwrite the existing files.
+    operand files*
+`
+
+const testJSON = `{
+  "schema": 1,
+  "app": {
+    "name": "backup",
+    "options": [
+      {"long": "dry-run", "short": "n", "type": "bool"},
+      {"long": "ratio", "type": "float64", "default": "0.5"}
+    ]
+  }
+}`
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args   []string
+		spec   string
+		status int
+		stdout string
+		stderr string
+	}{
+		{
+			args:   []string{"--dsl", "-", "--", "-v", "-l", "best", "it's", "dst"},
+			spec:   testDSL,
+			stdout: "verbose='true'\nlevel='best'\njobs='2'\nsrc='it'\\''s'\ndest='dst'\ncommand=''\nset -- 'it'\\''s' 'dst'\n",
+		},
+		{
+			args:   []string{"-d", "-", "-p", "opt_", "--", "-j", "4", "r", "-f", "a", "b"},
+			spec:   testDSL,
+			stdout: "opt_verbose='false'\nopt_level='fast'\nopt_jobs='4'\nopt_force='true'\nopt_command='restore'\nset -- 'a' 'b'\n",
+		},
+		{
+			args:   []string{"--spec", "-", "--", "-n"},
+			spec:   testJSON,
+			stdout: "dry_run='true'\nratio='0.5'\ncommand=''\nset --\n",
+		},
+		{
+			args:   []string{"-d", "-", "--", "restore", "--help"},
+			spec:   testDSL,
+			stdout: "printf '%s' 'backup restore - Restores a backup.\n",
+		},
+		{
+			args:   []string{"-d", "-", "--", "-l", "bad", "x"},
+			spec:   testDSL,
+			status: 1,
+			stdout: "exit 1\n",
+			stderr: "error: error parsing argument '-l'",
+		},
+		{
+			args:   []string{"-d", "-", "--"},
+			spec:   "app backup\n  flag x\n",
+			status: 2,
+			stdout: "exit 2\n",
+			stderr: "error: line 2: unknown keyword 'flag'\n",
+		},
+		{
+			args:   []string{"-d", "-", "--"},
+			spec:   "app backup\n  option jobs int=many\n",
+			status: 2,
+			stdout: "exit 2\n",
+			stderr: "error: invalid spec at app.options[0]: invalid default value",
+		},
+		{
+			args:   []string{"--", "x"},
+			status: 2,
+			stdout: "exit 2\n",
+			stderr: "error: exactly one of '--spec' or '--dsl' must be used\n",
+		},
+		{
+			args:   []string{"--help"},
+			stdout: "printf '%s' 'libcmd-getopt - Parses the arguments of shell scripts.\n",
+		},
+		{
+			args:   []string{"-d", "-", "--"},
+			spec:   "app backup\n  option command\n",
+			status: 2,
+			stdout: "exit 2\n",
+			stderr: "error: the command path and the option 'command' of 'backup' use the same variable 'command'\n",
+		},
+		{
+			args:   []string{"-d", "-", "-p", "opt_", "--"},
+			spec:   "app backup\n  option dry-run\n  option dry_run\n",
+			status: 2,
+			stdout: "exit 2\n",
+			stderr: "error: the option 'dry-run' and the option 'dry_run' of 'backup' use the same variable 'opt_dry_run'\n",
+		},
+		{
+			args:   []string{"-d", "-", "--"},
+			spec:   "app backup\n  option src,s string\n  operand src\n",
+			status: 2,
+			stdout: "exit 2\n",
+			stderr: "error: the option 'src' and the operand 'src' of 'backup' use the same variable 'src'\n",
+		},
+		{
+			args:   []string{"-d", "-", "--"},
+			spec:   "app backup\n  option force\n  command restore\n    option force\n",
+			status: 2,
+			stdout: "exit 2\n",
+			stderr: "error: the option 'force' and the option 'force' of 'backup restore' use the same variable 'force'\n",
+		},
+		{
+			args:   []string{"-d", "-", "--"},
+			spec:   "app backup\n  operand PATH\n",
+			status: 2,
+			stdout: "exit 2\n",
+			stderr: "error: the operand 'PATH' of 'backup' overwrites the shell variable 'PATH' (use '--prefix')\n",
+		},
+		{
+			args:   []string{"-d", "-", "-p", "arg_", "--", "/bin"},
+			spec:   "app backup\n  operand PATH\n",
+			stdout: "arg_PATH='/bin'\narg_command=''\nset -- '/bin'\n",
+		},
+	}
+
+	for i, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(test.args, strings.NewReader(test.spec), &stdout, &stderr)
+
+		if status != test.status {
+			t.Errorf("Case %d, expected status %d, got %d", i, test.status, status)
+		}
+
+		if !strings.HasPrefix(stdout.String(), test.stdout) {
+			t.Errorf("Case %d, expected stdout to start with:\n%s\ngot:\n%s", i, test.stdout, stdout.String())
+		}
+
+		if !strings.HasPrefix(stderr.String(), test.stderr) {
+			t.Errorf("Case %d, expected stderr to start with:\n%s\ngot:\n%s", i, test.stderr, stderr.String())
+		}
+	}
+}
+
+func TestRunWrittenSpec(t *testing.T) {
+	app := libcmd.NewApp("backup", "Backs up a directory.")
+	app.Options.Version = "1.0"
+	app.Options.HelpCommand = true
+	app.Options.VersionFlag = true
+	app.Options.SpecFlag = true
+	app.Bool("verbose", 'v', false, "Print every copied file.")
+	app.Command("restore", "Restores a backup.", func(cmd *libcmd.Cmd) {
+		cmd.Bool("force", 'f', false, "Overwrite the existing files.")
+	})
+
+	var spec strings.Builder
+	if err := app.WriteSpec(&spec); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args   []string
+		stdout string
+	}{
+		{args: []string{"restore", "-f"}, stdout: "verbose='false'\nforce='true'\ncommand='restore'\nset --\n"},
+		{args: []string{"help", "restore"}, stdout: "printf '%s' 'backup restore - Restores a backup.\n"},
+		{args: []string{"--version"}, stdout: "printf '%s' 'backup version 1.0\n'\nexit 0\n"},
+	}
+
+	for i, test := range tests {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"--spec", "-", "--"}, test.args...)
+		if status := run(args, strings.NewReader(spec.String()), &stdout, &stderr); status != 0 {
+			t.Errorf("Case %d, expected status 0, got %d (%s)", i, status, stderr.String())
+		}
+
+		if !strings.HasPrefix(stdout.String(), test.stdout) {
+			t.Errorf("Case %d, expected stdout to start with:\n%s\ngot:\n%s", i, test.stdout, stdout.String())
+		}
+	}
+}
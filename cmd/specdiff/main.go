@@ -0,0 +1,89 @@
+// Command specdiff compares two JSON specs of a command-line interface, as
+// written by App.WriteSpec, and reports the differences between them. It
+// exits with status 1 when there are breaking changes, so it can be used
+// to fail a build that breaks the compatibility with a previous release.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+// returned by the run callback when there are breaking changes
+var errBreaking = errors.New("breaking changes found")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// runs the tool, returning the exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	app := libcmd.NewApp("specdiff", "Compares two command-line specs.")
+	app.Long = "Reports the breaking changes (and, optionally, the additive ones) between the specs of two versions of an app, as written by the '--spec' flag of libcmd apps."
+	app.Options.StrictOperands = true
+	app.Options.PrintUsageOnError = true
+	app.Options.HelpOutput = stdout
+	app.Options.ErrorOutput = stderr
+
+	all := app.Bool("all", 'a', false, "Report the additive changes too.")
+	app.AddOperand("old", "")
+	app.AddOperand("new", "")
+
+	app.Run(func(cmd *libcmd.Cmd) error {
+		previous, err := readSpec(cmd.Operand("old"))
+		if err != nil {
+			return err
+		}
+
+		current, err := readSpec(cmd.Operand("new"))
+		if err != nil {
+			return err
+		}
+
+		var breaking bool
+		for _, change := range libcmd.CompareSpecs(previous, current) {
+			breaking = breaking || change.Breaking
+
+			if change.Breaking || *all {
+				fmt.Fprintln(stdout, change)
+			}
+		}
+
+		if breaking {
+			return errBreaking
+		}
+
+		return nil
+	})
+
+	err := app.ParseArgs(args)
+	switch {
+	case err == nil:
+		return 0
+	case err == errBreaking:
+		return 1
+	case !libcmd.IsParserErr(err):
+		fmt.Fprintf(stderr, "error: %v\n", err)
+	}
+
+	return 2
+}
+
+func readSpec(name string) (*libcmd.Spec, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	spec, err := libcmd.ReadSpec(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	return spec, nil
+}
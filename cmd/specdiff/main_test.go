@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	oldSpec = `{"schema": 1, "app": {"name": "app", "options": [{"long": "verbose", "short": "v", "type": "bool"}]}}`
+	newSpec = `{"schema": 1, "app": {"name": "app", "options": [{"long": "verbose", "short": "v", "type": "bool"}, {"long": "jobs", "type": "int"}]}}`
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "specdiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldFile := filepath.Join(dir, "old.json")
+	newFile := filepath.Join(dir, "new.json")
+	if err := ioutil.WriteFile(oldFile, []byte(oldSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(newFile, []byte(newSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args   []string
+		status int
+		stdout string
+		stderr string
+	}{
+		{args: []string{oldFile, oldFile}, status: 0},
+		{args: []string{oldFile, newFile}, status: 0},
+		{args: []string{"-a", oldFile, newFile}, status: 0, stdout: "additive: app --jobs: option added\n"},
+		{args: []string{newFile, oldFile}, status: 1, stdout: "breaking: app --jobs: option removed\n"},
+		{args: []string{oldFile, filepath.Join(dir, "missing.json")}, status: 2, stderr: "error: open " + filepath.Join(dir, "missing.json") + ": no such file or directory\n"},
+	}
+
+	for i, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(test.args, &stdout, &stderr)
+
+		if status != test.status {
+			t.Errorf("Case %d, expected status %d, got %d", i, test.status, status)
+		}
+
+		if stdout.String() != test.stdout {
+			t.Errorf("Case %d, expected stdout '%s', got '%s'", i, test.stdout, stdout.String())
+		}
+
+		if stderr.String() != test.stderr {
+			t.Errorf("Case %d, expected stderr '%s', got '%s'", i, test.stderr, stderr.String())
+		}
+	}
+}
+
+func TestRunParseError(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"only-one.json"}, &stdout, &stderr); status != 2 {
+		t.Errorf("Expected status 2, got %d", status)
+	}
+
+	if stderr.Len() == 0 {
+		t.Errorf("Expected the parse error on stderr")
+	}
+}
@@ -1,6 +1,7 @@
 package libcmd_test
 
 import (
+	"io"
 	"io/ioutil"
 	"testing"
 
@@ -298,3 +299,197 @@ func TestNoMatch(t *testing.T) {
 		t.Errorf("Command should not be called")
 	}
 }
+
+func TestOptionsInheritance(t *testing.T) {
+	tests := []struct {
+		cmd       []string
+		strict    bool
+		expectErr bool
+	}{
+		{cmd: []string{"copy", "a", "b"}},
+		{cmd: []string{"copy", "a"}, expectErr: true},
+		{cmd: []string{"move", "a"}},
+		{cmd: []string{"move", "a", "b", "c"}},
+		{cmd: []string{"move", "a"}, strict: true, expectErr: true},
+		{cmd: []string{"copy", "a"}, strict: true, expectErr: true},
+	}
+
+	for i, test := range tests {
+		app := libcmd.NewApp("app", "")
+		app.Options.HelpOutput = ioutil.Discard
+		app.Options.StrictOperands = test.strict
+
+		app.Command("copy", "", func(cmd *libcmd.Cmd) {
+			cmd.Options.StrictOperands = true
+			cmd.AddOperand("src", "")
+			cmd.AddOperand("dst", "")
+			cmd.Run(func(*libcmd.Cmd) error { return nil })
+		})
+
+		app.Command("move", "", func(cmd *libcmd.Cmd) {
+			cmd.AddOperand("src", "")
+			cmd.AddOperand("dst", "")
+			cmd.Run(func(cmd *libcmd.Cmd) error {
+				if cmd.EffectiveOptions().HelpOutput != ioutil.Discard {
+					t.Errorf("Case %d, HelpOutput should be inherited from the parent command", i)
+				}
+				return nil
+			})
+		})
+
+		err := app.ParseArgs(test.cmd)
+		if test.expectErr && !libcmd.IsParserErr(err) {
+			t.Errorf("Case %d, should have returned error", i)
+		} else if !test.expectErr && err != nil {
+			t.Errorf("Case %d, error running parser: %v", i, err)
+		}
+
+		if app.Options.StrictOperands != test.strict {
+			t.Errorf("Case %d, parent options should not be changed by the subcommand", i)
+		}
+	}
+}
+
+func TestOptionsOverride(t *testing.T) {
+	var appHelp, subHelp bool
+
+	app := libcmd.NewApp("app", "")
+	app.Options.OnHelp = func(*libcmd.Cmd, io.Writer) { appHelp = true }
+
+	app.Command("sub", "", func(cmd *libcmd.Cmd) {
+		cmd.Options.OnHelp = func(*libcmd.Cmd, io.Writer) { subHelp = true }
+		cmd.Bool("flag", 'f', false, "")
+	})
+
+	if err := app.ParseArgs([]string{"sub", "-h"}); err != nil {
+		t.Errorf("Error running parser: %v", err)
+	}
+
+	if appHelp || !subHelp {
+		t.Errorf("Subcommand help callback should override the parent one (app: %v, sub: %v)", appHelp, subHelp)
+	}
+}
+
+func TestOptionsOverrideZero(t *testing.T) {
+	app := libcmd.NewApp("app", "")
+	app.Options.HelpOutput = ioutil.Discard
+	app.Options.StrictOperands = true
+
+	app.Command("sub", "", func(cmd *libcmd.Cmd) {
+		cmd.Options.StrictOperands = false
+		cmd.Options.HelpOutput = nil
+		cmd.Options.Override("StrictOperands", "HelpOutput")
+
+		cmd.AddOperand("src", "")
+		cmd.AddOperand("dst", "")
+		cmd.Run(func(cmd *libcmd.Cmd) error {
+			options := cmd.EffectiveOptions()
+			if options.StrictOperands || options.HelpOutput != nil {
+				t.Errorf("The overridden options should not be inherited")
+			}
+
+			return nil
+		})
+	})
+
+	if err := app.ParseArgs([]string{"sub", "a"}); err != nil {
+		t.Errorf("Error running parser: %v", err)
+	}
+
+	if !app.Options.StrictOperands || app.Options.HelpOutput != ioutil.Discard {
+		t.Errorf("The parent options should not be changed by the subcommand")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("An unknown option name should panic")
+		}
+	}()
+
+	app.Options.Override("Strict")
+}
+
+func TestCommandAlias(t *testing.T) {
+	tests := []struct {
+		cmd    []string
+		remove bool
+		list   bool
+	}{
+		{cmd: []string{"remove"}, remove: true},
+		{cmd: []string{"rm"}, remove: true},
+		{cmd: []string{"del"}, remove: true},
+		{cmd: []string{"list"}, list: true},
+		{cmd: []string{"ls"}},
+	}
+
+	for i, test := range tests {
+		var remove, list bool
+		app := libcmd.NewApp("app", "")
+
+		app.CommandRun("remove", "", func(*libcmd.Cmd) error {
+			remove = true
+			return nil
+		}).Aliases = []string{"rm", "del"}
+
+		app.CommandRun("list", "", func(*libcmd.Cmd) error {
+			list = true
+			return nil
+		}).Hidden = true
+
+		if err := app.ParseArgs(test.cmd); err != nil {
+			t.Errorf("Case %d, error running parser: %v", i, err)
+			continue
+		}
+
+		compareValue(t, i, test.remove, remove)
+		compareValue(t, i, test.list, list)
+	}
+}
+
+func TestCommandAliasConflict(t *testing.T) {
+	tests := []struct {
+		setup    func(app *libcmd.App)
+		expected string
+	}{
+		{
+			setup: func(app *libcmd.App) {
+				app.Command("remove", "", nil)
+				app.Command("remove", "", nil)
+			},
+			expected: "duplicated command name: remove",
+		},
+		{
+			setup: func(app *libcmd.App) {
+				app.Command("remove", "", nil).Aliases = []string{"rm"}
+				app.Command("rm", "", nil)
+			},
+			expected: "duplicated command name: rm",
+		},
+		{
+			setup: func(app *libcmd.App) {
+				app.Command("remove", "", nil).Aliases = []string{"list"}
+				app.Command("list", "", nil)
+				app.ParseArgs([]string{"list"})
+			},
+			expected: "duplicated command name: list",
+		},
+		{
+			setup: func(app *libcmd.App) {
+				app.Command("remove", "", nil).Aliases = []string{"x"}
+				app.Command("list", "", nil).Aliases = []string{"x"}
+				app.ParseArgs([]string{"x"})
+			},
+			expected: "duplicated command name: x",
+		},
+	}
+
+	for i, test := range tests {
+		func() {
+			defer func() {
+				compareValue(t, i, test.expected, recover())
+			}()
+
+			test.setup(libcmd.NewApp("app", ""))
+		}()
+	}
+}
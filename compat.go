@@ -0,0 +1,305 @@
+package libcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// SpecChange describes a difference between two versions of the
+// command-line interface of an app (see CompareSpecs).
+type SpecChange struct {
+	// The command path of the changed item, like 'app sub' or
+	// 'app sub --name'
+	Path string
+
+	// What changed
+	Message string
+
+	// True if the change can break existing command lines, like
+	// removing an option or narrowing it's choices
+	Breaking bool
+}
+
+// String describes the change in a single line, like
+// 'breaking: app --verbose: option removed'.
+func (c SpecChange) String() string {
+	kind := "additive"
+	if c.Breaking {
+		kind = "breaking"
+	}
+
+	return fmt.Sprintf("%s: %s: %s", kind, c.Path, c.Message)
+}
+
+// ReadSpec reads a JSON spec, as written by App.WriteSpec. Specs with a
+// schema newer than SpecSchema are rejected.
+func ReadSpec(reader io.Reader) (*Spec, error) {
+	var spec Spec
+
+	if err := json.NewDecoder(reader).Decode(&spec); err != nil {
+		return nil, err
+	}
+
+	if spec.Schema > SpecSchema {
+		return nil, fmt.Errorf("unsupported spec schema %d (the newest supported is %d)", spec.Schema, SpecSchema)
+	}
+
+	return &spec, nil
+}
+
+// CompareSpecs compares the specs of two versions of an app, returning
+// every difference that matters to the users: removed and added commands,
+// aliases, options and choices, changed types and defaults and new
+// required operands. Changes to the help texts are not reported.
+func CompareSpecs(previous, current *Spec) []SpecChange {
+	changes := make([]SpecChange, 0)
+	compareCommands(&previous.App, &current.App, previous.App.Name, &changes)
+	return changes
+}
+
+// CheckCompatibility returns an error describing the breaking changes
+// between the specs of two versions of an app, or nil if there are none.
+// It is meant to be used in tests, comparing the spec of the last release
+// with the current one.
+func CheckCompatibility(previous, current *Spec) error {
+	var lines []string
+
+	for _, change := range CompareSpecs(previous, current) {
+		if change.Breaking {
+			lines = append(lines, change.String())
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("the command-line interface has breaking changes:\n%s", strings.Join(lines, "\n"))
+}
+
+func compareCommands(previous, current *SpecCommand, path string, changes *[]SpecChange) {
+	report := func(path string, breaking bool, format string, args ...interface{}) {
+		*changes = append(*changes, SpecChange{Path: path, Message: fmt.Sprintf(format, args...), Breaking: breaking})
+	}
+
+	// aliases
+	for _, alias := range previous.Aliases {
+		if !containsString(current.Aliases, alias) && alias != current.Name {
+			report(path, true, "alias '%s' removed", alias)
+		}
+	}
+
+	for _, alias := range current.Aliases {
+		if !containsString(previous.Aliases, alias) && alias != previous.Name {
+			report(path, false, "alias '%s' added", alias)
+		}
+	}
+
+	if previous.Deprecated == nil && current.Deprecated != nil {
+		report(path, false, "command deprecated")
+	}
+
+	compareOperands(previous.Operands, current.Operands, path, report)
+	compareOptions(previous.Options, current.Options, path, report)
+
+	// subcommands, matched by name or alias
+	for i := range previous.Commands {
+		c := &previous.Commands[i]
+
+		if match := findSpecCommand(current.Commands, c.Name); match != nil {
+			if match.Name != c.Name {
+				report(path+" "+c.Name, false, "command renamed to '%s'", match.Name)
+			}
+
+			compareCommands(c, match, path+" "+c.Name, changes)
+		} else {
+			report(path+" "+c.Name, true, "command removed")
+		}
+	}
+
+	for i := range current.Commands {
+		c := &current.Commands[i]
+
+		if findSpecCommand(previous.Commands, c.Name) == nil && !specCommandRenamed(previous.Commands, c) {
+			report(path+" "+c.Name, false, "command added")
+		}
+	}
+}
+
+func compareOperands(previous, current []SpecOperand, path string, report func(string, bool, string, ...interface{})) {
+	previousRequired := requiredOperands(previous)
+	currentRequired := requiredOperands(current)
+
+	if currentRequired > previousRequired {
+		report(path, true, "required operands increased from %d to %d", previousRequired, currentRequired)
+	} else if currentRequired < previousRequired {
+		report(path, false, "required operands decreased from %d to %d", previousRequired, currentRequired)
+	}
+}
+
+func compareOptions(previous, current []SpecOption, path string, report func(string, bool, string, ...interface{})) {
+	for _, opt := range previous {
+		names := opt.names()
+		if len(names) == 0 {
+			continue
+		}
+
+		var match *SpecOption
+		for _, name := range names {
+			if m := findSpecOption(current, name); m != nil {
+				match = m
+				break
+			}
+		}
+
+		if match == nil {
+			report(path+" "+names[0], true, "option removed")
+			continue
+		}
+
+		optPath := path + " " + names[0]
+		currentNames := match.names()
+
+		for _, name := range names {
+			if !containsString(currentNames, name) {
+				report(optPath, true, "name '%s' removed", name)
+			}
+		}
+
+		for _, name := range currentNames {
+			if !containsString(names, name) {
+				report(optPath, false, "name '%s' added", name)
+			}
+		}
+
+		switch {
+		case opt.Type == match.Type:
+			// nothing changed
+
+		case opt.Type == "choice" && match.Type == "string":
+			report(optPath, false, "type changed from 'choice' to 'string'")
+
+		default:
+			report(optPath, true, "type changed from '%s' to '%s'", opt.Type, match.Type)
+		}
+
+		if opt.Type == "choice" && match.Type == "choice" {
+			for _, value := range opt.Choices {
+				if !containsString(match.Choices, value) {
+					report(optPath, true, "choice '%s' removed", value)
+				}
+			}
+
+			for _, value := range match.Choices {
+				if !containsString(opt.Choices, value) {
+					report(optPath, false, "choice '%s' added", value)
+				}
+			}
+		}
+
+		if opt.Default != match.Default {
+			report(optPath, false, "default changed from '%s' to '%s'", opt.Default, match.Default)
+		}
+
+		if opt.Deprecated == nil && match.Deprecated != nil {
+			report(optPath, false, "option deprecated")
+		}
+	}
+
+	for _, opt := range current {
+		names := opt.names()
+
+		var found bool
+		for _, name := range names {
+			if findSpecOption(previous, name) != nil {
+				found = true
+				break
+			}
+		}
+
+		if !found && len(names) > 0 {
+			report(path+" "+names[0], false, "option added")
+		}
+	}
+}
+
+// the names of the argument, as used in the command-line
+func (spec SpecOption) names() []string {
+	names := make([]string, 0, len(spec.Aliases)+2)
+
+	if spec.Long != "" {
+		names = append(names, "--"+spec.Long)
+	}
+
+	if spec.Short != "" {
+		names = append(names, "-"+spec.Short)
+	}
+
+	for _, alias := range spec.Aliases {
+		if utf8.RuneCountInString(alias) == 1 {
+			names = append(names, "-"+alias)
+		} else {
+			names = append(names, "--"+alias)
+		}
+	}
+
+	return names
+}
+
+// find an argument by any of it's names (with dashes)
+func findSpecOption(options []SpecOption, name string) *SpecOption {
+	for i := range options {
+		if containsString(options[i].names(), name) {
+			return &options[i]
+		}
+	}
+
+	return nil
+}
+
+// find a command by name or alias
+func findSpecCommand(commands []SpecCommand, name string) *SpecCommand {
+	for i := range commands {
+		if commands[i].Name == name || containsString(commands[i].Aliases, name) {
+			return &commands[i]
+		}
+	}
+
+	return nil
+}
+
+// checks if the command is the new name of one of the old commands,
+// i. e. one of it's aliases is the name of an old command
+func specCommandRenamed(previous []SpecCommand, c *SpecCommand) bool {
+	for _, alias := range c.Aliases {
+		if findSpecCommand(previous, alias) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func requiredOperands(operands []SpecOperand) int {
+	var n int
+	for _, op := range operands {
+		if op.Modifier == "" {
+			n++
+		}
+	}
+
+	return n
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package libcmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func TestCompareSpecs(t *testing.T) {
+	previous := libcmd.NewApp("app", "")
+	previous.Choice([]string{"red", "green"}, "color", 'c', "")
+	previous.String("jobs", 'j', "")
+	previous.Bool("verbose", 'v', false)
+	previous.Command("copy", "", func(cmd *libcmd.Cmd) {
+		cmd.AddOperand("src", "")
+	}).Aliases = []string{"cp"}
+	previous.Command("mv", "", nil)
+	previous.Command("remove", "", nil)
+
+	current := libcmd.NewApp("app", "")
+	current.Choice([]string{"red", "blue"}, "colour", 'c', "red")
+	current.Opt("colour").Aliases = []string{"color"}
+	current.Int("jobs", 'j', 1)
+	current.Bool("debug", 'd', false)
+	current.Command("copy", "", func(cmd *libcmd.Cmd) {
+		cmd.AddOperand("src", "")
+		cmd.AddOperand("dst", "")
+	})
+	current.Command("move", "", nil).Aliases = []string{"mv"}
+	current.Command("list", "", nil)
+
+	expected := []string{
+		"additive: app --color: name '--colour' added",
+		"breaking: app --color: choice 'green' removed",
+		"additive: app --color: choice 'blue' added",
+		"additive: app --color: default changed from '' to 'red'",
+		"breaking: app --jobs: type changed from 'string' to 'int'",
+		"additive: app --jobs: default changed from '' to '1'",
+		"breaking: app --verbose: option removed",
+		"additive: app --debug: option added",
+		"breaking: app copy: alias 'cp' removed",
+		"breaking: app copy: required operands increased from 1 to 2",
+		"additive: app mv: command renamed to 'move'",
+		"breaking: app remove: command removed",
+		"additive: app list: command added",
+	}
+
+	changes := libcmd.CompareSpecs(previous.Spec(), current.Spec())
+	actual := make([]string, 0, len(changes))
+	for _, change := range changes {
+		actual = append(actual, change.String())
+	}
+
+	compareArgs(t, 0, expected, actual)
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	previous := libcmd.NewApp("app", "")
+	previous.Choice([]string{"red"}, "color", 'c', "")
+
+	current := libcmd.NewApp("app", "")
+	current.Choice([]string{"red", "green"}, "color", 'c', "")
+	current.Command("list", "", nil)
+
+	if err := libcmd.CheckCompatibility(previous.Spec(), current.Spec()); err != nil {
+		t.Errorf("Additive changes should be compatible, got: %v", err)
+	}
+
+	if err := libcmd.CheckCompatibility(current.Spec(), previous.Spec()); err == nil {
+		t.Errorf("Removing a command and a choice should be incompatible")
+	}
+}
+
+func TestReadSpec(t *testing.T) {
+	var b strings.Builder
+	app, _ := manApp()
+	if err := app.WriteSpec(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := libcmd.ReadSpec(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changes := libcmd.CompareSpecs(app.Spec(), spec); len(changes) != 0 {
+		t.Errorf("A spec read back should have no changes, got: %v", changes)
+	}
+
+	if _, err := libcmd.ReadSpec(strings.NewReader(`{"schema": 999}`)); err == nil {
+		t.Errorf("A newer schema should be rejected")
+	}
+}
@@ -0,0 +1,277 @@
+package libcmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// the hidden command used by the dynamic completion
+const completeCommand = "__complete"
+
+// Completion is a candidate to complete a word of the command line.
+type Completion struct {
+	// The value that completes the word
+	Value string
+
+	// An optional description, shown by the shells that support it
+	Description string
+}
+
+// CompletionDirective tells the shell what to do with the completion
+// candidates. Directives can be combined with '|'.
+type CompletionDirective int
+
+const (
+	// CompleteNoSpace asks the shell to not add a space after
+	// the completed word
+	CompleteNoSpace CompletionDirective = 1 << iota
+
+	// CompleteFilesOnly asks the shell to complete file names,
+	// ignoring the candidates
+	CompleteFilesOnly
+
+	// CompleteDirsOnly asks the shell to complete directory names,
+	// ignoring the candidates
+	CompleteDirsOnly
+)
+
+// prints the candidates to complete the last word of the command line,
+// one per line, with the description separated by a tab. The last line
+// contains the directive, prefixed by ':'
+func (cmd *Cmd) printCandidates(words []string, writer io.Writer) {
+	candidates, directive := cmd.candidates(words)
+
+	for _, c := range candidates {
+		if c.Description != "" {
+			fmt.Fprintf(writer, "%s\t%s\n", c.Value, c.Description)
+		} else {
+			fmt.Fprintln(writer, c.Value)
+		}
+	}
+
+	fmt.Fprintf(writer, ":%d\n", directive)
+}
+
+// the candidates to complete the last word of the command line. The other
+// words are parsed (without running any callback) to find out the command
+// and what is being completed: an argument value, an argument name, a
+// subcommand or an operand
+func (cmd *Cmd) candidates(words []string) ([]Completion, CompletionDirective) {
+	var prefix string
+	if len(words) > 0 {
+		prefix = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	leaf, err := cmd.dispatch(words, true)
+	if err != nil {
+		// the value of the last argument is being completed
+		if e, ok := err.(noValueErr); ok && leaf != nil {
+			if entry := leaf.findOpt(e.arg); entry != nil {
+				return filterCandidates(entry.candidates(leaf, prefix))
+			}
+		}
+
+		return nil, 0
+	}
+
+	// after the first operand, everything else is an operand too
+	if len(leaf.args) == 0 && strings.HasPrefix(prefix, "-") {
+		// the '--name=value' form
+		if arg := parseOptArg(prefix); arg.isEq {
+			entry := leaf.findOpt(arg.name)
+			if entry == nil || entry.val.isBool {
+				return nil, 0
+			}
+
+			candidates, directive := filterCandidates(entry.candidates(leaf, arg.value))
+			for i := range candidates {
+				candidates[i].Value = arg.name + "=" + candidates[i].Value
+			}
+
+			return candidates, directive
+		}
+
+		return filterCandidates(leaf.flagCandidates(), prefix, 0)
+	}
+
+	var candidates []Completion
+	var directive CompletionDirective
+
+	if len(leaf.args) == 0 {
+		for _, c := range visibleCommands(leaf) {
+			for _, name := range append([]string{c.Name}, c.Aliases...) {
+				candidates = append(candidates, Completion{Value: name, Description: c.Brief})
+			}
+		}
+	}
+
+	if op := leaf.operandAt(len(leaf.args)); op != nil && op.complete != nil {
+		values, opDirective := op.complete(leaf, prefix)
+		candidates = append(candidates, values...)
+		directive |= opDirective
+	} else if op != nil {
+		for _, value := range op.choices {
+			candidates = append(candidates, Completion{Value: value})
+		}
+	}
+
+	return filterCandidates(candidates, prefix, directive)
+}
+
+// the candidate values of an argument
+func (entry *optEntry) candidates(cmd *Cmd, prefix string) ([]Completion, string, CompletionDirective) {
+	if entry.Complete != nil {
+		candidates, directive := entry.Complete(cmd, prefix)
+		return candidates, prefix, directive
+	}
+
+	var candidates []Completion
+	if choice, ok := entry.val.raw.(*choiceString); ok {
+		for _, value := range choice.choices {
+			if value != "" {
+				candidates = append(candidates, Completion{Value: value})
+			}
+		}
+	}
+
+	return candidates, prefix, 0
+}
+
+// the names of the visible arguments
+func (cmd *Cmd) flagCandidates() []Completion {
+	var candidates []Completion
+
+	for _, entry := range visibleOptions(cmd) {
+		var brief string
+		if len(entry.help) > 0 {
+			brief = entry.help[0]
+		}
+
+		if entry.long != "" {
+			candidates = append(candidates, Completion{Value: "--" + entry.long, Description: brief})
+		}
+
+		if entry.short != 0 {
+			candidates = append(candidates, Completion{Value: "-" + string(entry.short), Description: brief})
+		}
+	}
+
+	return candidates
+}
+
+// the operand documented at the specified position; a repeating
+// operand in the last position matches every position after it
+func (cmd *Cmd) operandAt(index int) *operand {
+	if index < len(cmd.operands) {
+		return &cmd.operands[index]
+	}
+
+	if n := len(cmd.operands); n > 0 && cmd.operands[n-1].modifier == "*" {
+		return &cmd.operands[n-1]
+	}
+
+	return nil
+}
+
+// discards the candidates that do not start with the prefix
+func filterCandidates(candidates []Completion, prefix string, directive CompletionDirective) ([]Completion, CompletionDirective) {
+	filtered := make([]Completion, 0, len(candidates))
+
+	for _, c := range candidates {
+		if strings.HasPrefix(c.Value, prefix) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return filtered, directive
+}
+
+func dynamicBashCompletion(root *completionNode, nodes []*completionNode, writer io.Writer) {
+	function := "_" + completionIdent(root.path) + "_completion"
+
+	fmt.Fprintf(writer, "# bash completion for %s\n", root.path)
+	fmt.Fprintf(writer, "%s() {\n", function)
+	fmt.Fprintf(writer, "    local cur directive line word prefix='' i\n")
+	fmt.Fprintf(writer, "    local -a words lines candidates\n\n")
+	fmt.Fprintf(writer, "    # '--opt=value' is split by COMP_WORDBREAKS, so it is joined back\n")
+	fmt.Fprintf(writer, "    for ((i = 1; i <= COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(writer, "        word=\"${COMP_WORDS[i]}\"\n")
+	fmt.Fprintf(writer, "        if [[ ${#words[@]} -gt 0 && ( \"$word\" == \"=\" && \"${words[${#words[@]}-1]}\" == --* || \"${words[${#words[@]}-1]}\" == --*= ) ]]; then\n")
+	fmt.Fprintf(writer, "            words[${#words[@]}-1]+=\"$word\"\n")
+	fmt.Fprintf(writer, "        else\n            words+=(\"$word\")\n        fi\n    done\n\n")
+	fmt.Fprintf(writer, "    cur=\"${words[${#words[@]}-1]}\"\n")
+	fmt.Fprintf(writer, "    if [[ \"$COMP_WORDBREAKS\" == *=* && \"$cur\" == --*=* ]]; then\n")
+	fmt.Fprintf(writer, "        prefix=\"${cur%%%%=*}=\"\n        cur=\"${cur#*=}\"\n    fi\n\n")
+	fmt.Fprintf(writer, "    mapfile -t lines < <(\"${COMP_WORDS[0]}\" %s \"${words[@]}\" 2>/dev/null)\n", completeCommand)
+	fmt.Fprintf(writer, "    if [[ ${#lines[@]} -eq 0 ]]; then\n        return\n    fi\n\n")
+	fmt.Fprintf(writer, "    directive=\"${lines[${#lines[@]}-1]#:}\"\n")
+	fmt.Fprintf(writer, "    unset 'lines[${#lines[@]}-1]'\n\n")
+	fmt.Fprintf(writer, "    # the shell completes only the value, after the '='\n")
+	fmt.Fprintf(writer, "    for line in \"${lines[@]}\"; do\n        line=\"${line%%%%$'\\t'*}\"\n        candidates+=(\"${line#\"$prefix\"}\")\n    done\n\n")
+	fmt.Fprintf(writer, "    if (( directive & %d )); then\n        COMPREPLY=($(compgen -d -- \"$cur\"))\n", CompleteDirsOnly)
+	fmt.Fprintf(writer, "    elif (( directive & %d )); then\n        COMPREPLY=($(compgen -f -- \"$cur\"))\n", CompleteFilesOnly)
+	fmt.Fprintf(writer, "    else\n        COMPREPLY=(\"${candidates[@]}\")\n    fi\n\n")
+	fmt.Fprintf(writer, "    if (( directive & %d )); then\n        compopt -o nospace\n    fi\n}\n\n", CompleteNoSpace)
+	fmt.Fprintf(writer, "complete -o default -F %s %s\n", function, shellQuote(root.path))
+}
+
+func dynamicZshCompletion(root *completionNode, nodes []*completionNode, writer io.Writer) {
+	function := "_" + completionIdent(root.path)
+
+	fmt.Fprintf(writer, "#compdef %s\n\n", root.path)
+	fmt.Fprintf(writer, "%s() {\n", function)
+	fmt.Fprintf(writer, "    local directive line value\n")
+	fmt.Fprintf(writer, "    local -a lines candidates\n\n")
+	fmt.Fprintf(writer, "    lines=(\"${(@f)$(\"${words[1]}\" %s \"${(@)words[2,CURRENT]}\" 2>/dev/null)}\")\n", completeCommand)
+	fmt.Fprintf(writer, "    if [[ \"${lines[-1]}\" != :* ]]; then\n        return\n    fi\n\n")
+	fmt.Fprintf(writer, "    directive=\"${lines[-1]#:}\"\n")
+	fmt.Fprintf(writer, "    lines=(\"${(@)lines[1,-2]}\")\n\n")
+	fmt.Fprintf(writer, "    if (( directive & %d )); then\n        _files -/\n        return\n", CompleteDirsOnly)
+	fmt.Fprintf(writer, "    elif (( directive & %d )); then\n        _files\n        return\n    fi\n\n", CompleteFilesOnly)
+	fmt.Fprintf(writer, "    for line in \"${lines[@]}\"; do\n")
+	fmt.Fprintf(writer, "        value=\"${${line%%%%$'\\t'*}//:/\\\\:}\"\n")
+	fmt.Fprintf(writer, "        if [[ \"$line\" == *$'\\t'* ]]; then\n")
+	fmt.Fprintf(writer, "            candidates+=(\"$value:${line#*$'\\t'}\")\n")
+	fmt.Fprintf(writer, "        else\n            candidates+=(\"$value\")\n        fi\n    done\n\n")
+	fmt.Fprintf(writer, "    if [[ ${#candidates} -eq 0 ]]; then\n        _files\n")
+	fmt.Fprintf(writer, "    elif (( directive & %d )); then\n        _describe %s candidates -S ''\n", CompleteNoSpace, shellQuote(root.path))
+	fmt.Fprintf(writer, "    else\n        _describe %s candidates\n    fi\n}\n\n", shellQuote(root.path))
+	fmt.Fprintf(writer, "if [ \"$funcstack[1]\" = %s ]; then\n    %s \"$@\"\nelse\n    compdef %s %s\nfi\n", shellQuote(function), function, function, shellQuote(root.path))
+}
+
+func dynamicFishCompletion(root *completionNode, nodes []*completionNode, writer io.Writer) {
+	function := "__" + completionIdent(root.path) + "_complete"
+
+	fmt.Fprintf(writer, "# fish completion for %s\n", root.path)
+	fmt.Fprintf(writer, "function %s\n", function)
+	fmt.Fprintf(writer, "    set -l words (commandline -opc)\n")
+	fmt.Fprintf(writer, "    set -l current (commandline -ct)\n")
+	fmt.Fprintf(writer, "    set -l lines (command $words[1] %s $words[2..-1] \"$current\" 2>/dev/null)\n", completeCommand)
+	fmt.Fprintf(writer, "    set -l directive (string replace -r '^:' '' -- $lines[-1])\n")
+	fmt.Fprintf(writer, "    set -e lines[-1]\n\n")
+	fmt.Fprintf(writer, "    if test (math \"$directive & %d\") -ne 0\n        __fish_complete_directories \"$current\"\n", CompleteDirsOnly)
+	fmt.Fprintf(writer, "    else if test (math \"$directive & %d\") -ne 0; or test (count $lines) -eq 0\n        __fish_complete_path \"$current\"\n", CompleteFilesOnly)
+	fmt.Fprintf(writer, "    else\n        string join \\n -- $lines\n    end\nend\n\n")
+	fmt.Fprintf(writer, "complete -c %s -f -a %s\n", fishQuote(root.path), fishQuote("("+function+")"))
+}
+
+func dynamicPowershellCompletion(root *completionNode, nodes []*completionNode, writer io.Writer) {
+	fmt.Fprintf(writer, "# powershell completion for %s\n", root.path)
+	fmt.Fprintf(writer, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", powershellQuote(root.path))
+	fmt.Fprintf(writer, "    param($wordToComplete, $commandAst, $cursorPosition)\n\n")
+	fmt.Fprintf(writer, "    $program = $commandAst.CommandElements[0].ToString()\n")
+	fmt.Fprintf(writer, "    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })\n\n")
+	fmt.Fprintf(writer, "    # older versions do not pass empty arguments to native commands\n")
+	fmt.Fprintf(writer, "    if ($wordToComplete -eq '' -and $PSVersionTable.PSVersion -lt [version]'7.3') {\n        $words += '\"\"'\n    } else {\n        $words += $wordToComplete\n    }\n\n")
+	fmt.Fprintf(writer, "    $lines = @(& $program %s @words 2>$null)\n", completeCommand)
+	fmt.Fprintf(writer, "    if ($lines.Count -eq 0) {\n        return\n    }\n\n")
+	fmt.Fprintf(writer, "    $directive = [int]$lines[-1].TrimStart(':')\n")
+	fmt.Fprintf(writer, "    if ($directive -band %d) {\n        return\n    }\n\n", CompleteFilesOnly|CompleteDirsOnly)
+	fmt.Fprintf(writer, "    $lines | Select-Object -SkipLast 1 | ForEach-Object {\n")
+	fmt.Fprintf(writer, "        $value, $description = $_ -split \"`t\", 2\n")
+	fmt.Fprintf(writer, "        if (-not $description) {\n            $description = $value\n        }\n\n")
+	fmt.Fprintf(writer, "        [System.Management.Automation.CompletionResult]::new($value, $value, 'ParameterValue', $description)\n")
+	fmt.Fprintf(writer, "    }\n}\n")
+}
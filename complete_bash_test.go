@@ -0,0 +1,103 @@
+//go:build !windows
+// +build !windows
+
+package libcmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func bashCompletionApp() *libcmd.App {
+	app := libcmd.NewApp("app", "")
+	app.Options.CompletionCommand = true
+	app.Options.DynamicCompletion = true
+	app.Choice([]string{"red", "green"}, "color", 'c', "", "The color.")
+	app.Command("sub", "Runs a subcommand.", nil)
+
+	return app
+}
+
+// runs as the completed app, started by the script of TestDynamicBashCompletion
+func TestDynamicBashCompletionHelper(t *testing.T) {
+	if os.Getenv("LIBCMD_COMPLETE_HELPER") != "1" {
+		t.Skip("only runs as a helper process")
+	}
+
+	args := os.Args
+	for i, arg := range args {
+		if arg == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+
+	bashCompletionApp().ParseArgs(args)
+	os.Exit(0)
+}
+
+func TestDynamicBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not available")
+	}
+
+	dir, err := ioutil.TempDir("", "libcmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the app, as called by the completion script
+	wrapper := "#!/bin/sh\nexec '" + os.Args[0] + "' -test.run='^TestDynamicBashCompletionHelper$' -- \"$@\"\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "app"), []byte(wrapper), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var script strings.Builder
+	if err := bashCompletionApp().PrintCompletion("bash", &script); err != nil {
+		t.Fatal(err)
+	}
+
+	scriptFile := filepath.Join(dir, "completion.bash")
+	if err := ioutil.WriteFile(scriptFile, []byte(script.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		words    string
+		expected string
+	}{
+		{words: "app ''", expected: "completion sub"},
+		{words: "app --color ''", expected: "red green"},
+		{words: "app --color r", expected: "red"},
+		{words: "app --color = ''", expected: "red green"},
+		{words: "app --color = g", expected: "green"},
+		{words: "app --color = red s", expected: "sub"},
+	}
+
+	for i, test := range tests {
+		code := "source '" + scriptFile + "'\n" +
+			"COMP_WORDS=(" + test.words + ")\n" +
+			"COMP_CWORD=$((${#COMP_WORDS[@]} - 1))\n" +
+			"_app_completion\n" +
+			"echo \"${COMPREPLY[*]}\"\n"
+
+		cmd := exec.Command(bash, "--norc", "--noprofile", "-c", code)
+		cmd.Env = append(os.Environ(), "LIBCMD_COMPLETE_HELPER=1", "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+		output, err := cmd.Output()
+		if err != nil {
+			t.Errorf("Case %d, error running bash: %v", i, err)
+			continue
+		}
+
+		compareValue(t, i, test.expected, strings.TrimSpace(string(output)))
+	}
+}
@@ -0,0 +1,124 @@
+package libcmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func TestDynamicCompletion(t *testing.T) {
+	tests := []struct {
+		words    []string
+		expected string
+	}{
+		{words: []string{""}, expected: "completion\tPrints the shell completion script.\nremote\tManages the remotes.\nrm\tManages the remotes.\nstatus\tShows the status.\n:0\n"},
+		{words: []string{"st"}, expected: "status\tShows the status.\n:0\n"},
+		{words: []string{"-"}, expected: "--verbose\n-v\n--color\tThe color.\n-c\tThe color.\n--help\tShow this help message.\n-h\tShow this help message.\n:0\n"},
+		{words: []string{"--color", ""}, expected: "red\ngreen\n:0\n"},
+		{words: []string{"-vc", "g"}, expected: "green\n:0\n"},
+		{words: []string{"--color=r"}, expected: "--color=red\n:0\n"},
+		{words: []string{"remote", "--name", ""}, expected: "origin\tThe main one.\nupstream\n:1\n"},
+		{words: []string{"rm", "--name", "up"}, expected: "upstream\n:1\n"},
+		{words: []string{"remote", "--name", "origin", ""}, expected: "origin/main\norigin/dev\n:0\n"},
+		{words: []string{"remote", "a", "b", "origin/d"}, expected: "origin/dev\n:0\n"},
+		{words: []string{"remote", "--out", ""}, expected: ":4\n"},
+		{words: []string{"status", ""}, expected: ":2\n"},
+		{words: []string{"--unknown", ""}, expected: ":0\n"},
+		{words: []string{"completion", "b"}, expected: "bash\n:0\n"},
+	}
+
+	for i, test := range tests {
+		var b strings.Builder
+		app := libcmd.NewApp("app", "")
+		app.Options.DynamicCompletion = true
+		app.Options.CompletionCommand = true
+		app.Options.HelpOutput = &b
+
+		app.Bool("verbose", 'v', false)
+		app.Choice([]string{"red", "green"}, "color", 'c', "", "The color.")
+
+		remote := app.Command("remote", "Manages the remotes.", func(cmd *libcmd.Cmd) {
+			name := cmd.String("name", 'n', "", "The remote name.")
+			cmd.Opt("name").Complete = func(*libcmd.Cmd, string) ([]libcmd.Completion, libcmd.CompletionDirective) {
+				return []libcmd.Completion{{Value: "origin", Description: "The main one."}, {Value: "upstream"}}, libcmd.CompleteNoSpace
+			}
+
+			cmd.String("out", 'o', "", "The output directory.")
+			cmd.Opt("out").Complete = func(*libcmd.Cmd, string) ([]libcmd.Completion, libcmd.CompletionDirective) {
+				return nil, libcmd.CompleteDirsOnly
+			}
+
+			cmd.AddOperand("branch", "*")
+			cmd.CompleteOperand("branch", func(c *libcmd.Cmd, prefix string) ([]libcmd.Completion, libcmd.CompletionDirective) {
+				if *name == "" {
+					*name = "origin"
+				}
+
+				return []libcmd.Completion{{Value: *name + "/main"}, {Value: *name + "/dev"}}, 0
+			})
+
+			cmd.Run(func(*libcmd.Cmd) error {
+				t.Errorf("Case %d, the command should not run", i)
+				return nil
+			})
+		})
+		remote.Aliases = []string{"rm"}
+
+		app.Command("status", "Shows the status.", func(cmd *libcmd.Cmd) {
+			cmd.AddOperand("file", "")
+			cmd.CompleteOperand("file", func(*libcmd.Cmd, string) ([]libcmd.Completion, libcmd.CompletionDirective) {
+				return nil, libcmd.CompleteFilesOnly
+			})
+		})
+
+		args := append([]string{"__complete"}, test.words...)
+		if err := app.ParseArgs(args); err != nil {
+			t.Errorf("Case %d, error running parser: %v", i, err)
+			continue
+		}
+
+		compareValue(t, i, test.expected, b.String())
+	}
+}
+
+func TestDynamicCompletionScripts(t *testing.T) {
+	tests := []struct {
+		shell string
+		file  string
+	}{
+		{shell: "bash", file: "testdata/completion-dynamic-bash.golden"},
+		{shell: "zsh", file: "testdata/completion-dynamic-zsh.golden"},
+		{shell: "fish", file: "testdata/completion-dynamic-fish.golden"},
+		{shell: "powershell", file: "testdata/completion-dynamic-powershell.golden"},
+	}
+
+	for i, test := range tests {
+		app := libcmd.NewApp("app", "")
+		app.Options.CompletionCommand = true
+		app.Options.DynamicCompletion = true
+
+		if err := compareHelpOutput(app, []string{"completion", test.shell}, test.file); err != nil {
+			t.Errorf("Case %d, %v", i, err)
+		}
+	}
+}
+
+func TestDynamicCompletionErrHandler(t *testing.T) {
+	var b strings.Builder
+	app := libcmd.NewApp("app", "")
+	app.Options.DynamicCompletion = true
+	app.Options.HelpOutput = &b
+
+	app.Choice([]string{"red", "green"}, "color", 'c', "", "The color.")
+	app.Err(func(err error) error {
+		t.Errorf("The completion should not run the error handlers")
+		return nil
+	})
+
+	if err := app.ParseArgs([]string{"__complete", "--color", ""}); err != nil {
+		t.Fatal(err)
+	}
+
+	compareValue(t, 0, "red\ngreen\n:0\n", b.String())
+}
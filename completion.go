@@ -0,0 +1,610 @@
+package libcmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// a command, as seen by the completion scripts
+type completionNode struct {
+	path     string
+	names    []string
+	brief    string
+	flags    []completionFlag
+	operands []string
+	values   []string
+	commands []*completionNode
+}
+
+// an argument, as seen by the completion scripts
+type completionFlag struct {
+	names   []string
+	brief   string
+	value   bool
+	choices []string
+
+	// hidden arguments are never offered, but their values are skipped
+	hidden bool
+}
+
+// the generators of the completion scripts, by shell name
+var completionScripts = map[string]func(root *completionNode, nodes []*completionNode, writer io.Writer){
+	"bash":       bashCompletion,
+	"zsh":        zshCompletion,
+	"fish":       fishCompletion,
+	"powershell": powershellCompletion,
+}
+
+// the generators of the scripts that use the dynamic completion
+var dynamicCompletionScripts = map[string]func(root *completionNode, nodes []*completionNode, writer io.Writer){
+	"bash":       dynamicBashCompletion,
+	"zsh":        dynamicZshCompletion,
+	"fish":       dynamicFishCompletion,
+	"powershell": dynamicPowershellCompletion,
+}
+
+// PrintCompletion prints a script that adds tab completion of the app
+// commands and arguments to the specified shell. The supported shells are
+// 'bash', 'zsh', 'fish' and 'powershell'; any other name returns an error.
+//
+// The script is static: it knows the visible commands, their aliases, the
+// arguments (and the values of the ones defined with Choice, in both the
+// '--name value' and '--name=value' forms) and falls back to the shell file
+// completion for operands and other values. The values of the hidden
+// arguments are skipped, but the arguments themselves are never offered. The
+// subcommands are configured along the way (see Command).
+//
+// When Options.DynamicCompletion is set, the script asks the app itself for
+// the candidates, so the completion callbacks (see Opt.Complete and
+// Cmd.CompleteOperand) are used.
+func (app *App) PrintCompletion(shell string, writer io.Writer) error {
+	return app.printCompletion(shell, writer)
+}
+
+func (cmd *Cmd) printCompletion(shell string, writer io.Writer) error {
+	scripts := completionScripts
+	if cmd.EffectiveOptions().DynamicCompletion {
+		scripts = dynamicCompletionScripts
+	}
+
+	generator, ok := scripts[shell]
+	if !ok {
+		shells := make([]string, 0, len(completionScripts))
+		for name := range completionScripts {
+			shells = append(shells, name)
+		}
+
+		sort.Strings(shells)
+		return fmt.Errorf("unsupported shell '%s' (supported shells: %s)", shell, strings.Join(shells, ", "))
+	}
+
+	root := completionTree(cmd)
+
+	var nodes []*completionNode
+	var walk func(node *completionNode)
+	walk = func(node *completionNode) {
+		nodes = append(nodes, node)
+		for _, c := range node.commands {
+			walk(c)
+		}
+	}
+
+	walk(root)
+	generator(root, nodes, writer)
+	return nil
+}
+
+// the names of the supported shells, sorted
+func completionShells() []string {
+	shells := make([]string, 0, len(completionScripts))
+	for name := range completionScripts {
+		shells = append(shells, name)
+	}
+
+	sort.Strings(shells)
+	return shells
+}
+
+func (cmd *Cmd) setupCompletionCommand() {
+	c := cmd.Command("completion", "Prints the shell completion script.", nil)
+	c.AddOperand("shell", "")
+	c.operands[0].choices = completionShells()
+	c.Run(func(c *Cmd) error {
+		return cmd.printCompletion(c.Operand("shell"), cmd.helpOutput())
+	})
+}
+
+// collects the visible commands and arguments, recursively
+func completionTree(cmd *Cmd) *completionNode {
+	cmd.configure()
+	cmd.setupHelp()
+
+	node := &completionNode{
+		path:  cmd.path(),
+		names: append([]string{cmd.Name}, cmd.Aliases...),
+		brief: cmd.Brief,
+	}
+
+	for _, entry := range cmd.optentries {
+		if entry.Hidden && entry.val.isBool {
+			continue
+		}
+
+		flag := completionFlag{value: !entry.val.isBool, hidden: entry.Hidden}
+
+		if entry.long != "" {
+			flag.names = append(flag.names, "--"+entry.long)
+		}
+
+		if entry.short != 0 {
+			flag.names = append(flag.names, "-"+string(entry.short))
+		}
+
+		for _, alias := range entry.Aliases {
+			if utf8.RuneCountInString(alias) == 1 {
+				flag.names = append(flag.names, "-"+alias)
+			} else {
+				flag.names = append(flag.names, "--"+alias)
+			}
+		}
+
+		if len(entry.help) > 0 {
+			flag.brief = entry.help[0]
+		}
+
+		if choice, ok := entry.val.raw.(*choiceString); ok {
+			for _, value := range choice.choices {
+				if value != "" {
+					flag.choices = append(flag.choices, value)
+				}
+			}
+		}
+
+		node.flags = append(node.flags, flag)
+	}
+
+	for _, op := range cmd.operands {
+		node.operands = append(node.operands, op.name)
+		node.values = append(node.values, op.choices...)
+	}
+
+	for _, c := range visibleCommands(cmd) {
+		node.commands = append(node.commands, completionTree(c))
+	}
+
+	return node
+}
+
+// the arguments offered as candidates
+func (node *completionNode) visibleFlags() []completionFlag {
+	flags := make([]completionFlag, 0, len(node.flags))
+	for _, flag := range node.flags {
+		if !flag.hidden {
+			flags = append(flags, flag)
+		}
+	}
+
+	return flags
+}
+
+// all the names of the visible arguments
+func (node *completionNode) flagNames() []string {
+	names := make([]string, 0)
+	for _, flag := range node.visibleFlags() {
+		names = append(names, flag.names...)
+	}
+
+	return names
+}
+
+// all the names (and aliases) of the subcommands,
+// followed by the known values of the operands
+func (node *completionNode) commandNames() []string {
+	names := make([]string, 0)
+	for _, c := range node.commands {
+		names = append(names, c.names...)
+	}
+
+	return append(names, node.values...)
+}
+
+// the name of the app, usable as part of a function name
+func completionIdent(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, name)
+}
+
+// quotes a text with single quotes, as understood by bash and zsh
+func shellQuote(text string) string {
+	return "'" + strings.Replace(text, "'", `'\''`, -1) + "'"
+}
+
+// quotes a text with single quotes, as understood by fish
+func fishQuote(text string) string {
+	text = strings.Replace(text, `\`, `\\`, -1)
+	return "'" + strings.Replace(text, "'", `\'`, -1) + "'"
+}
+
+// quotes a text with single quotes, as understood by PowerShell
+func powershellQuote(text string) string {
+	return "'" + strings.Replace(text, "'", "''", -1) + "'"
+}
+
+// the patterns used by bash and zsh to follow the command path and to
+// skip the values of the arguments, as the branches of a 'case' statement.
+// Without the commands, only the arguments that take a value are matched
+func shellPathCases(nodes []*completionNode, commands bool, indent string, writer io.Writer) {
+	for _, node := range nodes {
+		for _, c := range node.commands {
+			if !commands {
+				break
+			}
+
+			patterns := make([]string, 0, len(c.names))
+			for _, name := range c.names {
+				patterns = append(patterns, shellQuote(node.path+" "+name))
+			}
+
+			fmt.Fprintf(writer, "%s%s) cmdpath=%s ;;\n", indent, strings.Join(patterns, "|"), shellQuote(c.path))
+		}
+
+		for _, flag := range node.flags {
+			if !flag.value {
+				continue
+			}
+
+			patterns := make([]string, 0, len(flag.names))
+			for _, name := range flag.names {
+				patterns = append(patterns, shellQuote(node.path+" "+name))
+			}
+
+			fmt.Fprintf(writer, "%s%s) opt=%s ;;\n", indent, strings.Join(patterns, "|"), shellQuote(node.path+" "+flag.names[0]))
+		}
+	}
+}
+
+func bashCompletion(root *completionNode, nodes []*completionNode, writer io.Writer) {
+	function := "_" + completionIdent(root.path) + "_completion"
+
+	fmt.Fprintf(writer, "# bash completion for %s\n", root.path)
+	fmt.Fprintf(writer, "%s() {\n", function)
+	fmt.Fprintf(writer, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\" cmdpath=%s opt='' prefix='' i\n\n", shellQuote(root.path))
+	fmt.Fprintf(writer, "    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(writer, "        if [[ -n \"$opt\" ]]; then\n")
+	fmt.Fprintf(writer, "            # '--opt=value', split by COMP_WORDBREAKS\n")
+	fmt.Fprintf(writer, "            [[ \"${COMP_WORDS[i]}\" == \"=\" ]] && continue\n")
+	fmt.Fprintf(writer, "            opt=''\n            continue\n        fi\n\n")
+	fmt.Fprintf(writer, "        case \"$cmdpath ${COMP_WORDS[i]}\" in\n")
+	shellPathCases(nodes, true, "            ", writer)
+	fmt.Fprintf(writer, "        esac\n    done\n\n")
+
+	fmt.Fprintf(writer, "    if [[ -n \"$opt\" && \"$cur\" == \"=\" ]]; then\n        cur=''\n")
+	fmt.Fprintf(writer, "    elif [[ -z \"$opt\" && \"$cur\" == --*=* ]]; then\n")
+	fmt.Fprintf(writer, "        case \"$cmdpath ${cur%%%%=*}\" in\n")
+	shellPathCases(nodes, false, "            ", writer)
+	fmt.Fprintf(writer, "        esac\n\n")
+	fmt.Fprintf(writer, "        [[ -z \"$opt\" ]] && return\n")
+	fmt.Fprintf(writer, "        prefix=\"${cur%%%%=*}=\"\n        cur=\"${cur#*=}\"\n    fi\n\n")
+
+	fmt.Fprintf(writer, "    if [[ -n \"$opt\" ]]; then\n        case \"$opt\" in\n")
+	for _, node := range nodes {
+		for _, flag := range node.flags {
+			if len(flag.choices) > 0 {
+				fmt.Fprintf(writer, "            %s) COMPREPLY=($(compgen -P \"$prefix\" -W %s -- \"$cur\")) ;;\n", shellQuote(node.path+" "+flag.names[0]), shellQuote(strings.Join(flag.choices, " ")))
+			}
+		}
+	}
+	fmt.Fprintf(writer, "        esac\n        return\n    fi\n\n")
+
+	fmt.Fprintf(writer, "    case \"$cmdpath\" in\n")
+	for _, node := range nodes {
+		fmt.Fprintf(writer, "        %s)\n", shellQuote(node.path))
+		fmt.Fprintf(writer, "            if [[ \"$cur\" == -* ]]; then\n")
+		fmt.Fprintf(writer, "                COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(node.flagNames(), " ")))
+		fmt.Fprintf(writer, "            else\n")
+		fmt.Fprintf(writer, "                COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(node.commandNames(), " ")))
+		fmt.Fprintf(writer, "            fi\n            ;;\n")
+	}
+	fmt.Fprintf(writer, "    esac\n}\n\n")
+
+	fmt.Fprintf(writer, "complete -o default -F %s %s\n", function, shellQuote(root.path))
+}
+
+// an item of the zsh '_describe' function, in the form 'name:description'
+func zshItem(name, brief string) string {
+	name = strings.Replace(name, ":", `\:`, -1)
+	if brief == "" {
+		return shellQuote(name)
+	}
+
+	return shellQuote(name + ":" + brief)
+}
+
+func zshCompletion(root *completionNode, nodes []*completionNode, writer io.Writer) {
+	function := "_" + completionIdent(root.path)
+
+	fmt.Fprintf(writer, "#compdef %s\n\n", root.path)
+	fmt.Fprintf(writer, "%s() {\n", function)
+	fmt.Fprintf(writer, "    local cmdpath=%s opt='' i\n    local -a items\n\n", shellQuote(root.path))
+	fmt.Fprintf(writer, "    for ((i = 2; i < CURRENT; i++)); do\n")
+	fmt.Fprintf(writer, "        if [[ -n \"$opt\" ]]; then\n            opt=''\n            continue\n        fi\n\n")
+	fmt.Fprintf(writer, "        case \"$cmdpath ${words[i]}\" in\n")
+	shellPathCases(nodes, true, "            ", writer)
+	fmt.Fprintf(writer, "        esac\n    done\n\n")
+
+	fmt.Fprintf(writer, "    if [[ -z \"$opt\" && \"$PREFIX\" == --*=* ]]; then\n")
+	fmt.Fprintf(writer, "        case \"$cmdpath ${PREFIX%%%%=*}\" in\n")
+	shellPathCases(nodes, false, "            ", writer)
+	fmt.Fprintf(writer, "        esac\n\n")
+	fmt.Fprintf(writer, "        [[ -n \"$opt\" ]] && compset -P '*='\n    fi\n\n")
+
+	fmt.Fprintf(writer, "    if [[ -n \"$opt\" ]]; then\n        case \"$opt\" in\n")
+	for _, node := range nodes {
+		for _, flag := range node.flags {
+			if len(flag.choices) > 0 {
+				quoted := make([]string, 0, len(flag.choices))
+				for _, value := range flag.choices {
+					quoted = append(quoted, shellQuote(value))
+				}
+
+				fmt.Fprintf(writer, "            %s) compadd -- %s ;;\n", shellQuote(node.path+" "+flag.names[0]), strings.Join(quoted, " "))
+			}
+		}
+	}
+	fmt.Fprintf(writer, "            *) _files ;;\n        esac\n        return\n    fi\n\n")
+
+	fmt.Fprintf(writer, "    case \"$cmdpath\" in\n")
+	for _, node := range nodes {
+		fmt.Fprintf(writer, "        %s)\n", shellQuote(node.path))
+		fmt.Fprintf(writer, "            if [[ \"$PREFIX\" == -* ]]; then\n")
+		fmt.Fprintf(writer, "                items=(")
+		for i, flag := range node.visibleFlags() {
+			for j, name := range flag.names {
+				if i > 0 || j > 0 {
+					fmt.Fprint(writer, " ")
+				}
+
+				fmt.Fprint(writer, zshItem(name, flag.brief))
+			}
+		}
+		fmt.Fprintf(writer, ")\n")
+
+		switch {
+		case len(node.commands) == 0 && len(node.values) > 0:
+			quoted := make([]string, 0, len(node.values))
+			for _, value := range node.values {
+				quoted = append(quoted, shellQuote(value))
+			}
+
+			fmt.Fprintf(writer, "            else\n                items=(%s)\n", strings.Join(quoted, " "))
+
+		case len(node.commands) > 0:
+			fmt.Fprintf(writer, "            else\n                items=(")
+			for i, c := range node.commands {
+				for j, name := range c.names {
+					if i > 0 || j > 0 {
+						fmt.Fprint(writer, " ")
+					}
+
+					fmt.Fprint(writer, zshItem(name, c.brief))
+				}
+			}
+			fmt.Fprintf(writer, ")\n")
+
+		case len(node.operands) > 0:
+			fmt.Fprintf(writer, "            else\n                _message %s\n                _files\n                return\n", shellQuote(strings.Join(node.operands, " ")))
+
+		default:
+			fmt.Fprintf(writer, "            else\n                _files\n                return\n")
+		}
+
+		fmt.Fprintf(writer, "            fi\n            ;;\n")
+	}
+	fmt.Fprintf(writer, "    esac\n\n")
+	fmt.Fprintf(writer, "    _describe %s items\n}\n\n", shellQuote(root.path))
+
+	fmt.Fprintf(writer, "if [ \"$funcstack[1]\" = %s ]; then\n    %s \"$@\"\nelse\n    compdef %s %s\nfi\n", shellQuote(function), function, function, shellQuote(root.path))
+}
+
+func fishCompletion(root *completionNode, nodes []*completionNode, writer io.Writer) {
+	function := "__" + completionIdent(root.path) + "_complete_path"
+
+	fmt.Fprintf(writer, "# fish completion for %s\n", root.path)
+	fmt.Fprintf(writer, "function %s\n", function)
+	fmt.Fprintf(writer, "    set -l cmdpath %s\n    set -l opt ''\n\n", fishQuote(root.path))
+	fmt.Fprintf(writer, "    for word in (commandline -opc)[2..-1]\n")
+	fmt.Fprintf(writer, "        if test -n \"$opt\"\n            set opt ''\n            continue\n        end\n\n")
+	fmt.Fprintf(writer, "        switch \"$cmdpath $word\"\n")
+	for _, node := range nodes {
+		for _, c := range node.commands {
+			patterns := make([]string, 0, len(c.names))
+			for _, name := range c.names {
+				patterns = append(patterns, fishQuote(node.path+" "+name))
+			}
+
+			fmt.Fprintf(writer, "            case %s\n                set cmdpath %s\n", strings.Join(patterns, " "), fishQuote(c.path))
+		}
+
+		for _, flag := range node.flags {
+			if !flag.value {
+				continue
+			}
+
+			patterns := make([]string, 0, len(flag.names))
+			for _, name := range flag.names {
+				patterns = append(patterns, fishQuote(node.path+" "+name))
+			}
+
+			fmt.Fprintf(writer, "            case %s\n                set opt %s\n", strings.Join(patterns, " "), fishQuote(node.path+" "+flag.names[0]))
+		}
+	}
+	fmt.Fprintf(writer, "        end\n    end\n\n")
+	fmt.Fprintf(writer, "    test \"$cmdpath\" = \"$argv[1]\"\nend\n")
+
+	for _, node := range nodes {
+		prefix := fmt.Sprintf("complete -c %s -n %s", fishQuote(root.path), fishQuote(function+" "+fishQuote(node.path)))
+		fmt.Fprintln(writer)
+
+		for _, c := range node.commands {
+			for _, name := range c.names {
+				line := prefix
+				if len(node.operands) == 0 {
+					line += " -f"
+				}
+
+				line += " -a " + fishQuote(name)
+				if c.brief != "" {
+					line += " -d " + fishQuote(c.brief)
+				}
+
+				fmt.Fprintln(writer, line)
+			}
+		}
+
+		for _, value := range node.values {
+			fmt.Fprintf(writer, "%s -f -a %s\n", prefix, fishQuote(value))
+		}
+
+		for _, flag := range node.visibleFlags() {
+			line := prefix
+
+			for _, name := range flag.names {
+				if strings.HasPrefix(name, "--") {
+					line += " -l " + fishQuote(strings.TrimPrefix(name, "--"))
+				} else {
+					line += " -s " + fishQuote(strings.TrimPrefix(name, "-"))
+				}
+			}
+
+			if flag.value {
+				line += " -r"
+			}
+
+			if len(flag.choices) > 0 {
+				line += " -f -a " + fishQuote(strings.Join(flag.choices, " "))
+			}
+
+			if flag.brief != "" {
+				line += " -d " + fishQuote(flag.brief)
+			}
+
+			fmt.Fprintln(writer, line)
+		}
+	}
+}
+
+// an item of the PowerShell completion list
+func powershellItem(name, brief string) string {
+	if brief == "" {
+		brief = name
+	}
+
+	return fmt.Sprintf("[pscustomobject]@{ Name = %s; Brief = %s }", powershellQuote(name), powershellQuote(brief))
+}
+
+// the branches of the PowerShell 'switch' used to follow the command path and
+// to skip the values of the arguments. Without the commands, only the
+// arguments that take a value are matched
+func powershellPathCases(nodes []*completionNode, commands bool, writer io.Writer) {
+	for _, node := range nodes {
+		for _, c := range node.commands {
+			if !commands {
+				break
+			}
+
+			for _, name := range c.names {
+				fmt.Fprintf(writer, "            %s { $cmdpath = %s }\n", powershellQuote(node.path+" "+name), powershellQuote(c.path))
+			}
+		}
+
+		for _, flag := range node.flags {
+			if !flag.value {
+				continue
+			}
+
+			for _, name := range flag.names {
+				fmt.Fprintf(writer, "            %s { $opt = %s }\n", powershellQuote(node.path+" "+name), powershellQuote(node.path+" "+flag.names[0]))
+			}
+		}
+	}
+}
+
+func powershellCompletion(root *completionNode, nodes []*completionNode, writer io.Writer) {
+	fmt.Fprintf(writer, "# powershell completion for %s\n", root.path)
+	fmt.Fprintf(writer, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", powershellQuote(root.path))
+	fmt.Fprintf(writer, "    param($wordToComplete, $commandAst, $cursorPosition)\n\n")
+	fmt.Fprintf(writer, "    $cmdpath = %s\n    $opt = ''\n    $items = @()\n\n", powershellQuote(root.path))
+	fmt.Fprintf(writer, "    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })\n")
+	fmt.Fprintf(writer, "    foreach ($word in $words) {\n")
+	fmt.Fprintf(writer, "        if ($opt) {\n            $opt = ''\n            continue\n        }\n\n")
+	fmt.Fprintf(writer, "        switch -exact (\"$cmdpath $word\") {\n")
+	powershellPathCases(nodes, true, writer)
+	fmt.Fprintf(writer, "        }\n    }\n\n")
+
+	fmt.Fprintf(writer, "    $prefix = ''\n")
+	fmt.Fprintf(writer, "    if (-not $opt -and $wordToComplete -like '--*=*') {\n")
+	fmt.Fprintf(writer, "        $name, $value = $wordToComplete -split '=', 2\n")
+	fmt.Fprintf(writer, "        switch -exact (\"$cmdpath $name\") {\n")
+	powershellPathCases(nodes, false, writer)
+	fmt.Fprintf(writer, "        }\n\n")
+	fmt.Fprintf(writer, "        if ($opt) {\n            $prefix = \"$name=\"\n            $wordToComplete = $value\n        }\n    }\n\n")
+
+	fmt.Fprintf(writer, "    if ($opt) {\n        switch -exact ($opt) {\n")
+	for _, node := range nodes {
+		for _, flag := range node.flags {
+			if len(flag.choices) == 0 {
+				continue
+			}
+
+			values := make([]string, 0, len(flag.choices))
+			for _, value := range flag.choices {
+				values = append(values, powershellItem(value, ""))
+			}
+
+			fmt.Fprintf(writer, "            %s { $items = @(%s) }\n", powershellQuote(node.path+" "+flag.names[0]), strings.Join(values, ", "))
+		}
+	}
+	fmt.Fprintf(writer, "        }\n    } else {\n        switch -exact ($cmdpath) {\n")
+	for _, node := range nodes {
+		items := make([]string, 0)
+		for _, flag := range node.visibleFlags() {
+			for _, name := range flag.names {
+				items = append(items, powershellItem(name, flag.brief))
+			}
+		}
+
+		for _, c := range node.commands {
+			for _, name := range c.names {
+				items = append(items, powershellItem(name, c.brief))
+			}
+		}
+
+		for _, value := range node.values {
+			items = append(items, powershellItem(value, ""))
+		}
+
+		fmt.Fprintf(writer, "            %s {\n                $items = @(\n", powershellQuote(node.path))
+		for i, item := range items {
+			sep := ","
+			if i == len(items)-1 {
+				sep = ""
+			}
+
+			fmt.Fprintf(writer, "                    %s%s\n", item, sep)
+		}
+		fmt.Fprintf(writer, "                )\n            }\n")
+	}
+	fmt.Fprintf(writer, "        }\n    }\n\n")
+
+	fmt.Fprintf(writer, "    $items | Where-Object { $_.Name -like \"$wordToComplete*\" -and ($wordToComplete -like '-*' -or $_.Name -notlike '-*') } | ForEach-Object {\n")
+	fmt.Fprintf(writer, "        [System.Management.Automation.CompletionResult]::new(\"$prefix$($_.Name)\", $_.Name, 'ParameterValue', $_.Brief)\n")
+	fmt.Fprintf(writer, "    }\n}\n")
+}
@@ -0,0 +1,62 @@
+package libcmd_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func completionApp() *libcmd.App {
+	app := libcmd.NewApp("app", "some brief description")
+	app.Options.CompletionCommand = true
+
+	app.Bool("verbose", 'v', false, "Show more details.")
+	app.Choice([]string{"red", "green"}, "color", 'c', "", "The color's name.")
+	app.String("secret", 0, "", "Not shown.")
+	app.Opt("secret").Hidden = true
+
+	sub := app.Command("sub", "Runs a subcommand.", func(cmd *libcmd.Cmd) {
+		cmd.String("name", 'n', "", "The name.")
+		cmd.Opt("name").Aliases = []string{"title"}
+		cmd.AddOperand("file", "*")
+
+		cmd.CommandRun("deep", "Goes deeper: even more.", nil)
+	})
+	sub.Aliases = []string{"s"}
+
+	app.Command("internal", "Not shown.", nil).Hidden = true
+
+	return app
+}
+
+func TestCompletion(t *testing.T) {
+	tests := []struct {
+		shell string
+		file  string
+	}{
+		{shell: "bash", file: "testdata/completion-bash.golden"},
+		{shell: "zsh", file: "testdata/completion-zsh.golden"},
+		{shell: "fish", file: "testdata/completion-fish.golden"},
+		{shell: "powershell", file: "testdata/completion-powershell.golden"},
+	}
+
+	for i, test := range tests {
+		if err := compareHelpOutput(completionApp(), []string{"completion", test.shell}, test.file); err != nil {
+			t.Errorf("Case %d, %v", i, err)
+		}
+	}
+}
+
+func TestCompletionUnknownShell(t *testing.T) {
+	app := completionApp()
+
+	if err := app.PrintCompletion("csh", ioutil.Discard); err == nil {
+		t.Errorf("An unsupported shell should return an error")
+	}
+
+	app.Options.HelpOutput = ioutil.Discard
+	if err := app.ParseArgs([]string{"completion", "csh"}); err == nil {
+		t.Errorf("An unsupported shell should return an error")
+	}
+}
@@ -0,0 +1,77 @@
+package libcmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// RunContext behaves like ParseArgs, making the context available to the
+// callbacks: it is passed to the callbacks registered with RunWithContext
+// and returned by Cmd.Context, so it can be used by the Match callbacks too.
+// If Options.CancelOnSignal is set, the context is also cancelled when the
+// process receives SIGINT or SIGTERM.
+func (app *App) RunContext(ctx context.Context, args []string) error {
+	if app.Options.CancelOnSignal {
+		var stop func()
+		ctx, stop = cancelOnSignal(ctx)
+		defer stop()
+	}
+
+	app.ctx = ctx
+	defer func() { app.ctx = nil }()
+
+	return app.doRun(args)
+}
+
+// Context returns the context of the app, as passed to App.RunContext.
+// When the parsing is started by Parse or ParseArgs (or outside of the
+// parsing), it returns an empty context.
+func (cmd *Cmd) Context() context.Context {
+	for cmd.parentCmd != nil {
+		cmd = cmd.parentCmd
+	}
+
+	if cmd.ctx == nil {
+		return context.Background()
+	}
+
+	return cmd.ctx
+}
+
+// returns a context that is cancelled on the first SIGINT or SIGTERM; the
+// second one exits the process, with the usual status (128 + the signal)
+func cancelOnSignal(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 2)
+	done := make(chan struct{})
+
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case sig := <-signals:
+			status := 1
+			if s, ok := sig.(syscall.Signal); ok {
+				status = 128 + int(s)
+			}
+
+			os.Exit(status)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
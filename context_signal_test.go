@@ -0,0 +1,71 @@
+//go:build !windows
+// +build !windows
+
+package libcmd_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+// runs as a separate process, started by TestCancelOnSignal
+func TestCancelOnSignalHelper(t *testing.T) {
+	if os.Getenv("LIBCMD_SIGNAL_HELPER") != "1" {
+		t.Skip("only runs as a helper process")
+	}
+
+	app := libcmd.NewApp("app", "")
+	app.Options.CancelOnSignal = true
+	app.RunWithContext(func(ctx context.Context, cmd *libcmd.Cmd) error {
+		fmt.Println("running")
+		<-ctx.Done()
+		fmt.Println("cancelled")
+
+		// a second signal should exit, even if the command does not stop
+		time.Sleep(time.Minute)
+		return nil
+	})
+
+	app.ParseArgs([]string{})
+	os.Exit(0)
+}
+
+func TestCancelOnSignal(t *testing.T) {
+	helper := exec.Command(os.Args[0], "-test.run=^TestCancelOnSignalHelper$")
+	helper.Env = append(os.Environ(), "LIBCMD_SIGNAL_HELPER=1")
+
+	stdout, err := helper.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := helper.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := bufio.NewScanner(stdout)
+	for _, expected := range []string{"running", "cancelled"} {
+		if expected == "cancelled" {
+			helper.Process.Signal(syscall.SIGTERM)
+		}
+
+		if !lines.Scan() || lines.Text() != expected {
+			t.Fatalf("Expected '%s', got '%s'", expected, lines.Text())
+		}
+	}
+
+	helper.Process.Signal(os.Interrupt)
+
+	err = helper.Wait()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 130 {
+		t.Errorf("The second signal should exit with status 130, got: %v", err)
+	}
+}
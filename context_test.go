@@ -0,0 +1,72 @@
+package libcmd_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+type contextKey string
+
+func TestRunContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey("key"), "value"))
+	defer cancel()
+
+	var matched, ran interface{}
+
+	app := libcmd.NewApp("app", "")
+	app.Command("sub", "", func(cmd *libcmd.Cmd) {
+		cmd.Match(func(cmd *libcmd.Cmd) {
+			matched = cmd.Context().Value(contextKey("key"))
+		})
+
+		cmd.RunWithContext(func(ctx context.Context, cmd *libcmd.Cmd) error {
+			ran = ctx.Value(contextKey("key"))
+			cancel()
+			return ctx.Err()
+		})
+	})
+
+	if err := app.RunContext(ctx, []string{"sub"}); err != context.Canceled {
+		t.Errorf("The error of the run callback should be returned, got: %v", err)
+	}
+
+	compareValue(t, 0, "value", matched)
+	compareValue(t, 0, "value", ran)
+
+	if app.Context() != context.Background() {
+		t.Errorf("The context should be reset after the parsing")
+	}
+}
+
+func TestRunContextParseArgs(t *testing.T) {
+	var ran bool
+
+	app := libcmd.NewApp("app", "")
+	app.RunWithContext(func(ctx context.Context, cmd *libcmd.Cmd) error {
+		ran = true
+
+		if ctx != context.Background() {
+			t.Errorf("ParseArgs should use an empty context")
+		}
+
+		return nil
+	})
+
+	if err := app.ParseArgs([]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	compareValue(t, 0, true, ran)
+
+	// Run replaces the callback
+	ran = false
+	app.Run(func(*libcmd.Cmd) error { return nil })
+
+	if err := app.ParseArgs([]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	compareValue(t, 0, false, ran)
+}
@@ -0,0 +1,152 @@
+package libcmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Deprecation describes an argument or command that should not be
+// used anymore. Every field is optional, and they are only used to
+// compose the warning message shown to the user.
+type Deprecation struct {
+	// Additional message shown along with the warning
+	Message string `json:"message,omitempty"`
+
+	// The name that should be used instead
+	Replacement string `json:"replacement,omitempty"`
+
+	// The version where the argument or command will be removed.
+	// When Options.Version is equal or greater than this value, the
+	// usage becomes an error instead of a warning.
+	RemovedIn string `json:"removedIn,omitempty"`
+}
+
+func (d *Deprecation) warning(name string) string {
+	s := fmt.Sprintf("'%s' is deprecated", name)
+
+	if d.RemovedIn != "" {
+		s += " and will be removed in version " + d.RemovedIn
+	}
+
+	if d.Replacement != "" {
+		s += fmt.Sprintf(", use '%s' instead", d.Replacement)
+	}
+
+	if d.Message != "" {
+		s += ": " + d.Message
+	}
+
+	return s
+}
+
+// checks if the deprecated name is already removed in the
+// specified version
+func (d *Deprecation) isRemoved(version string) bool {
+	return version != "" && d.RemovedIn != "" && compareVersions(version, d.RemovedIn) >= 0
+}
+
+// prints the deprecation warning of the name used in the
+// command-line, or returns an error if it was already removed
+func (cmd *Cmd) checkDeprecated(d *Deprecation, name string) error {
+	if d == nil {
+		return nil
+	}
+
+	if d.isRemoved(cmd.EffectiveOptions().Version) {
+		return removedErr{name: name, deprecation: d}
+	}
+
+	fmt.Fprintf(cmd.warningOutput(), "warning: %s\n", d.warning(name))
+	return nil
+}
+
+// compares two version strings, like '1.10.2' and 'v1.9-rc.1', using the
+// semantic versioning order: the numeric parts are compared as numbers
+// (missing parts are treated as zero) and a release comes after it's
+// pre-releases. The build metadata (after '+') is ignored.
+func compareVersions(a, b string) int {
+	acore, apre := splitVersion(a)
+	bcore, bpre := splitVersion(b)
+
+	if c := compareVersionParts(acore, bcore, "0"); c != 0 {
+		return c
+	}
+
+	// a release comes after it's pre-releases
+	switch {
+	case apre == nil && bpre == nil:
+		return 0
+	case apre == nil:
+		return 1
+	case bpre == nil:
+		return -1
+	}
+
+	return compareVersionParts(apre, bpre, "")
+}
+
+// splits a version like 'v1.2-rc.1+build' in it's numeric parts
+// and pre-release identifiers (nil for releases)
+func splitVersion(version string) ([]string, []string) {
+	version = strings.TrimPrefix(version, "v")
+
+	if i := strings.Index(version, "+"); i >= 0 {
+		version = version[:i]
+	}
+
+	var pre []string
+	if i := strings.Index(version, "-"); i >= 0 {
+		version, pre = version[:i], strings.Split(version[i+1:], ".")
+	}
+
+	return strings.Split(version, "."), pre
+}
+
+// compares the parts of two versions, the numeric ones by value and the
+// others as strings (numeric parts come first); the missing parts are
+// replaced by filler or, when it is empty, come before the existing ones
+func compareVersionParts(aparts, bparts []string, filler string) int {
+	for i := 0; i < len(aparts) || i < len(bparts); i++ {
+		ap, bp := filler, filler
+
+		if i < len(aparts) {
+			ap = aparts[i]
+		} else if filler == "" {
+			return -1
+		}
+
+		if i < len(bparts) {
+			bp = bparts[i]
+		} else if filler == "" {
+			return 1
+		}
+
+		an, aerr := strconv.Atoi(ap)
+		bn, berr := strconv.Atoi(bp)
+
+		switch {
+		case aerr == nil && berr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+
+		case aerr == nil:
+			return -1
+
+		case berr == nil:
+			return 1
+
+		case ap != bp:
+			if ap < bp {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,93 @@
+package libcmd_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func TestDeprecated(t *testing.T) {
+	tests := []struct {
+		cmd       []string
+		version   string
+		warning   string
+		expectErr string
+	}{
+		{cmd: []string{}},
+		{cmd: []string{"--new=a"}},
+		{cmd: []string{"--old=a"}, warning: "warning: '--old' is deprecated and will be removed in version 2.0, use '--new' instead\n"},
+		{cmd: []string{"-xo", "a"}, warning: "warning: '-o' is deprecated and will be removed in version 2.0, use '--new' instead\n"},
+		{cmd: []string{"--old=a"}, version: "1.9.3", warning: "warning: '--old' is deprecated and will be removed in version 2.0, use '--new' instead\n"},
+		{cmd: []string{"--old=a"}, version: "v2", expectErr: "'--old' was removed in version 2.0, use '--new' instead"},
+		{cmd: []string{"--old=a"}, version: "2.0.1", expectErr: "'--old' was removed in version 2.0, use '--new' instead"},
+		{cmd: []string{"--old=a"}, version: "10.0", expectErr: "'--old' was removed in version 2.0, use '--new' instead"},
+		{cmd: []string{"legacy"}, warning: "warning: 'legacy' is deprecated: it does nothing\n"},
+		{cmd: []string{"legacy"}, version: "99.0", warning: "warning: 'legacy' is deprecated: it does nothing\n"},
+		{cmd: []string{"retired"}, version: "1.0", warning: "warning: 'retired' is deprecated and will be removed in version 1.5-beta\n"},
+		{cmd: []string{"retired"}, version: "1.5-beta", expectErr: "'retired' was removed in version 1.5-beta"},
+		{cmd: []string{"gone"}, version: "1.6", expectErr: "'gone' was removed in version 1.5"},
+		{cmd: []string{"retired"}, version: "1.5-rc1", expectErr: "'retired' was removed in version 1.5-beta"},
+		{cmd: []string{"beta"}, version: "1.9", warning: "warning: 'beta' is deprecated and will be removed in version 1.10-beta\n"},
+		{cmd: []string{"beta"}, version: "1.10-alpha", warning: "warning: 'beta' is deprecated and will be removed in version 1.10-beta\n"},
+		{cmd: []string{"beta"}, version: "1.10-beta.2", expectErr: "'beta' was removed in version 1.10-beta"},
+		{cmd: []string{"beta"}, version: "1.10", expectErr: "'beta' was removed in version 1.10-beta"},
+		{cmd: []string{"final"}, version: "2.0-rc1", warning: "warning: 'final' is deprecated and will be removed in version 2.0\n"},
+		{cmd: []string{"final"}, version: "2.0-rc.10+build.5", warning: "warning: 'final' is deprecated and will be removed in version 2.0\n"},
+		{cmd: []string{"final"}, version: "2.0", expectErr: "'final' was removed in version 2.0"},
+	}
+
+	for i, test := range tests {
+		var b strings.Builder
+		app := libcmd.NewApp("app", "")
+		app.Options.HelpOutput = ioutil.Discard
+		app.Options.WarningOutput = &b
+		app.Options.Version = test.version
+
+		app.String("new", 0, "", "")
+		app.String("old", 'o', "", "")
+		app.Bool("", 'x', false, "")
+		app.Opt("old").Deprecated = &libcmd.Deprecation{Replacement: "--new", RemovedIn: "2.0"}
+
+		app.Command("legacy", "", nil).Deprecated = &libcmd.Deprecation{Message: "it does nothing"}
+		app.Command("retired", "", nil).Deprecated = &libcmd.Deprecation{RemovedIn: "1.5-beta"}
+		app.Command("beta", "", nil).Deprecated = &libcmd.Deprecation{RemovedIn: "1.10-beta"}
+		app.Command("final", "", nil).Deprecated = &libcmd.Deprecation{RemovedIn: "2.0"}
+		app.Command("gone", "", func(cmd *libcmd.Cmd) {
+			cmd.Deprecated = &libcmd.Deprecation{RemovedIn: "1.5"}
+		})
+
+		err := app.ParseArgs(test.cmd)
+		if test.expectErr == "" && err != nil {
+			t.Errorf("Case %d, error parsing args: %v", i, err)
+			continue
+		}
+
+		if test.expectErr != "" {
+			if !libcmd.IsParserErr(err) {
+				t.Errorf("Case %d, expected error but none received", i)
+				continue
+			}
+
+			compareValue(t, i, test.expectErr, err.Error())
+		}
+
+		compareValue(t, i, test.warning, b.String())
+	}
+}
+
+func TestHelpDeprecated(t *testing.T) {
+	app := libcmd.NewApp("app", "some brief description")
+
+	app.String("astring", 's', "", "Sets a string value.")
+	app.Int("aint", 'i', 0, "Sets a int value.")
+	app.Opt("aint").Deprecated = &libcmd.Deprecation{}
+
+	app.Command("add", "Sums two numbers.", nil)
+	app.Command("plus", "Sums two numbers.", nil).Deprecated = &libcmd.Deprecation{Replacement: "add"}
+
+	if err := compareHelpOutput(app, []string{"-h"}, "testdata/deprecated.golden"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,554 @@
+package libcmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// the default value on the help of an option, like '[default: 10]'
+	usageDefault = regexp.MustCompile(`(?i)\s*\[default:\s*([^\]]*)\]`)
+
+	// the separator between the names and the help of an option or command
+	usageColumns = regexp.MustCompile(`\s{2,}`)
+)
+
+// an option described on an usage text
+type usageOption struct {
+	long         string
+	short        rune
+	value        string
+	defaultValue string
+	help         string
+	group        string
+	line         int
+	used         bool
+}
+
+// a group ('[...]' or '(...)') of an usage pattern
+type usageGroup struct {
+	open     string
+	hasAlt   bool
+	hasOther bool
+}
+
+// a line of an usage text
+type usageLine struct {
+	text string
+	line int
+}
+
+type usageParser struct {
+	app *App
+
+	// the options of the options sections, followed by the
+	// ones found only on the patterns
+	options []*usageOption
+
+	// the commands with '[options]' on their patterns
+	withOptions []*Cmd
+}
+
+// NewAppFromUsage builds an app from a docopt-like help text, inferring
+// the commands, arguments and operands from it. The text starts with the
+// description of the app, followed by the 'Usage:' section, with one pattern
+// per line, and any number of options sections (like 'Options:' or
+// 'Global options:') and 'Commands:' sections:
+//
+//	Naval Fate.
+//
+//	Usage:
+//	  naval [options] ship new <name>...
+//	  naval ship move <name> <x> <y> [--speed=<kn>]
+//	  naval mine <x> <y> [-m | -d]
+//
+//	Options:
+//	  -v, --verbose  Print more details.
+//	  --speed=<kn>   Speed in knots [default: 10].
+//	  -m --moored    Moored (anchored) mine.
+//	  -d --drifting  Drifting mine.
+//
+//	Commands:
+//	  ship       Manages the ships.
+//	  ship move  Moves a ship.
+//
+// The first word of the patterns is the app name and the literal words that
+// follow it are the subcommands. Words like '<name>' or 'NAME' are operands,
+// optional when between brackets and repeating when followed by '...'.
+// The options appear on the patterns in the same position they have on the
+// command-line, so the ones before a subcommand belong to it's parent.
+//
+// The options with a value, like '--speed=<kn>', are string arguments, with
+// the default value taken from the '[default: x]' on their help; the other
+// ones are bool arguments. On the patterns, the value of an option described
+// with one on the options sections can also be separated by a space, like
+// '--speed <kn>'. Options that appear only on the options sections
+// are added to the commands with '[options]' on their patterns or, if there
+// is none, to the app. The name of the options section, unless it is just
+// 'Options', is used as the Group of it's options. The '--help' option is
+// skipped, since the app creates it automatically.
+//
+// The patterns can't have alternatives between commands or operands, optional
+// commands, repeated options or groups, nor operands after an optional or
+// repeating one (unless they are optional too, after an optional one); the
+// returned error points to the offending line. Before the 'Usage:' section,
+// only the options and commands headers start a section, so the description
+// can have other lines ending with ':'.
+//
+// Like in NewAppFromSpec, the runs parameter attaches a Run callback to the
+// commands, by their full path.
+func NewAppFromUsage(text string, runs map[string]RunCallback) (*App, error) {
+	var prelude []string
+	var patterns, commands []usageLine
+	var p usageParser
+	var last *usageOption
+	var section, group string
+
+	for i, line := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n") {
+		lineNo := i + 1
+		trimmed := strings.TrimSpace(line)
+		lower := strings.ToLower(trimmed)
+
+		// section headers are not indented
+		if trimmed != "" && !unicode.IsSpace(rune(line[0])) {
+			switch {
+			case strings.HasPrefix(lower, "usage:"):
+				section = "usage"
+				if pattern := strings.TrimSpace(trimmed[len("usage:"):]); pattern != "" {
+					patterns = append(patterns, usageLine{text: pattern, line: lineNo})
+				}
+				continue
+
+			case strings.HasSuffix(lower, "options:"):
+				section = "options"
+				group = strings.TrimSuffix(trimmed, ":")
+				if lower == "options:" {
+					group = ""
+				}
+				continue
+
+			case strings.HasSuffix(lower, "commands:"):
+				section = "commands"
+				continue
+
+			// other sections, but only after the usage; before it,
+			// the line is part of the description
+			case strings.HasSuffix(trimmed, ":") && section != "":
+				section = "other"
+				continue
+			}
+		}
+
+		switch section {
+		case "":
+			prelude = append(prelude, trimmed)
+
+		case "usage":
+			if trimmed == "" && len(patterns) > 0 {
+				section = "other"
+			} else if trimmed != "" {
+				patterns = append(patterns, usageLine{text: trimmed, line: lineNo})
+			}
+
+		case "options":
+			switch {
+			case strings.HasPrefix(trimmed, "-"):
+				opt, err := parseUsageOption(trimmed, group, lineNo)
+				if err != nil {
+					return nil, err
+				}
+
+				p.options = append(p.options, opt)
+				last = opt
+
+			case trimmed == "":
+				last = nil
+
+			case last != nil:
+				// the help continues on the next lines
+				last.help = strings.TrimSpace(last.help + " " + trimmed)
+			}
+
+		case "commands":
+			if trimmed != "" {
+				commands = append(commands, usageLine{text: trimmed, line: lineNo})
+			}
+		}
+	}
+
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("invalid usage: missing the 'Usage:' section")
+	}
+
+	for _, opt := range p.options {
+		if m := usageDefault.FindStringSubmatch(opt.help); m != nil {
+			opt.defaultValue = m[1]
+			opt.help = strings.TrimSpace(usageDefault.ReplaceAllString(opt.help, ""))
+		}
+	}
+
+	// the first paragraph is the brief; the others, the long description
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.Join(prelude, "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, strings.Join(strings.Fields(paragraph), " "))
+		}
+	}
+
+	var brief string
+	if len(paragraphs) > 0 {
+		brief, paragraphs = paragraphs[0], paragraphs[1:]
+	}
+
+	p.app = NewApp(usageTokens(patterns[0].text)[0], brief)
+	p.app.Long = strings.Join(paragraphs, "\n\n")
+
+	for _, pattern := range patterns {
+		if err := p.parsePattern(usageTokens(pattern.text), pattern.line); err != nil {
+			return nil, err
+		}
+	}
+
+	// the options used on no pattern
+	targets := p.withOptions
+	if len(targets) == 0 {
+		targets = []*Cmd{p.app.Cmd}
+	}
+
+	for _, opt := range p.options {
+		if opt.used {
+			continue
+		}
+
+		for _, cmd := range targets {
+			if err := cmd.defineUsageOption(opt); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, line := range commands {
+		columns := usageColumns.Split(line.text, 2)
+
+		cmd := p.app.findPath(p.app.Name + " " + columns[0])
+		if cmd == nil || cmd == p.app.Cmd {
+			return nil, usageErr{line: line.line, msg: fmt.Sprintf("unknown command '%s'", columns[0])}
+		}
+
+		if len(columns) > 1 {
+			cmd.Brief = columns[1]
+		}
+	}
+
+	if err := p.app.attachRuns(runs); err != nil {
+		return nil, err
+	}
+
+	return p.app, nil
+}
+
+// splits an usage pattern, keeping the brackets, parenthesis,
+// bars and ellipsis as separated tokens
+func usageTokens(pattern string) []string {
+	replacer := strings.NewReplacer("[", " [ ", "]", " ] ", "(", " ( ", ")", " ) ", "|", " | ", "...", " ... ")
+	return strings.Fields(replacer.Replace(pattern))
+}
+
+// parses an option of an options section, like '-s, --speed=<kn>  Speed.'
+func parseUsageOption(text, group string, line int) (*usageOption, error) {
+	opt := &usageOption{group: group, line: line}
+
+	columns := usageColumns.Split(text, 2)
+	if len(columns) > 1 {
+		opt.help = columns[1]
+	}
+
+	for _, field := range strings.Fields(strings.NewReplacer(",", " ", "=", " ").Replace(columns[0])) {
+		switch {
+		case strings.HasPrefix(field, "--") && len(field) > 2 && opt.long == "":
+			opt.long = field[2:]
+
+		case strings.HasPrefix(field, "-") && utf8.RuneCountInString(field) == 2 && opt.short == 0:
+			opt.short, _ = utf8.DecodeRuneInString(field[1:])
+
+		case !strings.HasPrefix(field, "-") && opt.value == "":
+			opt.value = strings.Trim(field, "<>")
+
+		default:
+			return nil, usageErr{line: line, msg: fmt.Sprintf("unexpected '%s'", field)}
+		}
+	}
+
+	return opt, nil
+}
+
+// defines the commands, arguments and operands of an usage pattern
+func (p *usageParser) parsePattern(tokens []string, line int) error {
+	fail := func(format string, args ...interface{}) error {
+		return usageErr{line: line, msg: fmt.Sprintf(format, args...)}
+	}
+
+	if tokens[0] != p.app.Name {
+		return fail("expected the app name '%s', got '%s'", p.app.Name, tokens[0])
+	}
+
+	// the whole pattern is the outermost group
+	cmd := p.app.Cmd
+	groups := []usageGroup{{}}
+	var operands []operand
+	var last string
+	var expectValue bool
+
+	for _, token := range tokens[1:] {
+		top := &groups[len(groups)-1]
+
+		// the value of an option, like '--speed <kn>'
+		if expectValue {
+			expectValue = false
+			if isUsageOperand(token) {
+				continue
+			}
+		}
+
+		switch {
+		case token == "[" || token == "(":
+			groups = append(groups, usageGroup{open: token})
+			last = ""
+
+		case token == "]" || token == ")":
+			if len(groups) == 1 || (token == "]") != (top.open == "[") {
+				return fail("unbalanced '%s'", token)
+			}
+
+			if top.hasAlt && top.hasOther {
+				return fail("alternatives between commands or operands are not supported")
+			}
+
+			groups = groups[:len(groups)-1]
+			groups[len(groups)-1].hasOther = groups[len(groups)-1].hasOther || top.hasOther
+			last = "group"
+
+		case token == "|":
+			top.hasAlt = true
+			last = ""
+
+		case token == "...":
+			if last == "group" {
+				return fail("repeated groups are not supported")
+			}
+
+			if last != "operand" {
+				return fail("repeated options are not supported")
+			}
+
+			operands[len(operands)-1].modifier = "*"
+
+		case token == "options" && len(groups) > 1:
+			p.withOptions = append(p.withOptions, cmd)
+			last = ""
+
+		case strings.HasPrefix(token, "-") && token != "-" && token != "--":
+			var err error
+			if expectValue, err = p.patternOption(cmd, token, line); err != nil {
+				return err
+			}
+
+			last = "option"
+
+		case isUsageOperand(token):
+			var modifier string
+			for _, g := range groups {
+				if g.open == "[" {
+					modifier = "?"
+				}
+			}
+
+			operands = append(operands, operand{name: strings.Trim(token, "<>"), modifier: modifier})
+			top.hasOther = true
+			last = "operand"
+
+		default:
+			if len(groups) > 1 || len(operands) > 0 {
+				return fail("the command '%s' must come before the operands and outside of groups", token)
+			}
+
+			next := cmd.findCommand(token)
+			if next == nil {
+				next = cmd.Command(token, "", nil)
+			}
+
+			cmd = next
+			top.hasOther = true
+			last = ""
+		}
+	}
+
+	if len(groups) > 1 {
+		return fail("unbalanced '%s'", groups[len(groups)-1].open)
+	}
+
+	if groups[0].hasAlt && groups[0].hasOther {
+		return fail("alternatives between commands or operands are not supported")
+	}
+
+	if len(operands) == 0 {
+		return nil
+	}
+
+	for i := 1; i < len(operands); i++ {
+		if operands[i-1].modifier == "*" {
+			return fail("the operand '%s' can't follow the repeating operand '%s'", operands[i].name, operands[i-1].name)
+		}
+
+		if operands[i-1].modifier != "" && operands[i].modifier == "" {
+			return fail("the required operand '%s' can't follow the optional operand '%s'", operands[i].name, operands[i-1].name)
+		}
+	}
+
+	// the patterns without operands (like 'app --version') don't count
+	if len(cmd.operands) > 0 {
+		if !sameOperands(cmd.operands, operands) {
+			return fail("the operands of '%s' differ from the ones of a previous pattern", cmd.path())
+		}
+
+		return nil
+	}
+
+	for _, op := range operands {
+		cmd.AddOperand(op.name, op.modifier)
+	}
+
+	return nil
+}
+
+// defines an option found on a pattern, like '--speed=<kn>' or '-abc'; returns
+// whether the option takes a value that is not on the token, like '--speed'
+// when the options section has '--speed=<kn>'
+func (p *usageParser) patternOption(cmd *Cmd, token string, line int) (bool, error) {
+	name, value := token, ""
+	if i := strings.Index(token, "="); i >= 0 {
+		name, value = token[:i], strings.Trim(token[i+1:], "<>")
+	}
+
+	names := []string{name}
+	if !strings.HasPrefix(name, "--") && utf8.RuneCountInString(name) > 2 {
+		if value != "" {
+			return false, usageErr{line: line, msg: fmt.Sprintf("unexpected value on '%s'", token)}
+		}
+
+		// stacked short options
+		names = nil
+		for _, r := range name[1:] {
+			names = append(names, "-"+string(r))
+		}
+	}
+
+	var expectValue bool
+	for _, name := range names {
+		opt := p.findOption(name)
+
+		if opt == nil {
+			opt = &usageOption{value: value, line: line}
+			if strings.HasPrefix(name, "--") {
+				opt.long = name[2:]
+			} else {
+				opt.short, _ = utf8.DecodeRuneInString(name[1:])
+			}
+
+			p.options = append(p.options, opt)
+		}
+
+		opt.used = true
+		if err := cmd.defineUsageOption(opt); err != nil {
+			return false, err
+		}
+
+		expectValue = value == "" && opt.value != ""
+	}
+
+	return expectValue, nil
+}
+
+// find an option by name (with '-' or '--')
+func (p *usageParser) findOption(name string) *usageOption {
+	for _, opt := range p.options {
+		if (opt.long != "" && name == "--"+opt.long) || (opt.short != 0 && name == "-"+string(opt.short)) {
+			return opt
+		}
+	}
+
+	return nil
+}
+
+// adds the argument of an option, unless the command already has it
+func (cmd *Cmd) defineUsageOption(opt *usageOption) error {
+	if opt.long == "help" {
+		return nil
+	}
+
+	if (opt.long != "" && cmd.findOpt("--"+opt.long) != nil) || (opt.short != 0 && cmd.findOpt("-"+string(opt.short)) != nil) {
+		return nil
+	}
+
+	help := []string{opt.help}
+	if opt.value != "" {
+		help = append(help, opt.value)
+	}
+
+	entry := &optEntry{long: opt.long, short: opt.short, help: help}
+
+	if opt.value != "" {
+		entry.val = varFromInterface(new(string), opt.defaultValue)
+	} else {
+		var defaultValue bool
+		if opt.defaultValue != "" {
+			var err error
+			if defaultValue, err = strconv.ParseBool(opt.defaultValue); err != nil {
+				return usageErr{line: opt.line, msg: fmt.Sprintf("invalid default value '%s'", opt.defaultValue)}
+			}
+		}
+
+		entry.val = varFromInterface(new(bool), defaultValue)
+	}
+
+	cmd.addOpt(entry)
+	entry.Group = opt.group
+	return nil
+}
+
+// checks if the token is an operand, like '<name>' or 'NAME'
+func isUsageOperand(token string) bool {
+	if strings.HasPrefix(token, "<") && strings.HasSuffix(token, ">") {
+		return true
+	}
+
+	var letters bool
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			letters = true
+		case !unicode.IsDigit(r) && r != '_' && r != '-':
+			return false
+		}
+	}
+
+	return letters
+}
+
+func sameOperands(a, b []operand) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].name != b[i].name || a[i].modifier != b[i].modifier {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,187 @@
+package libcmd_test
+
+import (
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+const navalUsage = `Naval Fate.
+
+Moves ships around and
+lays mines.
+
+Usage:
+  naval [options] ship new <name>...
+  naval ship move <name> <x> <y> [--speed <kn>]
+  naval mine [-m | -d] [-fq] <x> <y> [<tag>]
+  naval -h | --help
+
+Options:
+  -h --help      Show this screen.
+  -v, --verbose  Print more details.
+  --speed=<kn>   Speed in knots
+                 [default: 10].
+
+Mine options:
+  -m --moored    Moored (anchored) mine.
+  -d --drifting  Drifting mine.
+
+Commands:
+  ship       Manages the ships.
+  ship move  Moves a ship.
+`
+
+func TestNewAppFromUsage(t *testing.T) {
+	tests := []struct {
+		cmd      []string
+		path     string
+		verbose  bool
+		speed    string
+		moored   bool
+		force    bool
+		operands map[string]string
+		args     []string
+	}{
+		{cmd: []string{"ship", "new", "a", "b"}, path: "new", operands: map[string]string{"name": "a"}, args: []string{"a", "b"}},
+		{cmd: []string{"-v", "ship", "new", "a"}, path: "new", verbose: true, operands: map[string]string{"name": "a"}, args: []string{"a"}},
+		{cmd: []string{"ship", "move", "a", "1", "2"}, path: "move", speed: "10", operands: map[string]string{"name": "a", "x": "1", "y": "2"}, args: []string{"a", "1", "2"}},
+		{cmd: []string{"ship", "move", "--speed", "20", "a", "1", "2"}, path: "move", speed: "20", operands: map[string]string{"x": "1", "y": "2"}, args: []string{"a", "1", "2"}},
+		{cmd: []string{"mine", "-mf", "1", "2", "t"}, path: "mine", moored: true, force: true, operands: map[string]string{"tag": "t"}, args: []string{"1", "2", "t"}},
+	}
+
+	for i, test := range tests {
+		var path string
+		var verbose *bool
+
+		check := func(cmd *libcmd.Cmd) {
+			path = cmd.Name
+			compareArgs(t, i, test.args, cmd.Args())
+
+			for name, value := range test.operands {
+				compareValue(t, i, value, cmd.Operand(name))
+			}
+		}
+
+		app, err := libcmd.NewAppFromUsage(navalUsage, map[string]libcmd.RunCallback{
+			"naval ship new": func(cmd *libcmd.Cmd) error {
+				check(cmd)
+				verbose = cmd.Parent().Parent().GetBool("verbose")
+				return nil
+			},
+			"naval ship move": func(cmd *libcmd.Cmd) error {
+				check(cmd)
+				compareValue(t, i, test.speed, *cmd.GetString("speed"))
+				return nil
+			},
+			"naval mine": func(cmd *libcmd.Cmd) error {
+				check(cmd)
+				compareValue(t, i, test.moored, *cmd.GetBool("moored"))
+				compareValue(t, i, false, *cmd.GetBool("drifting"))
+				compareValue(t, i, test.force, *cmd.GetBool("f"))
+				compareValue(t, i, false, *cmd.GetBool("q"))
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		app.Options.StrictOperands = true
+
+		if err := app.ParseArgs(test.cmd); err != nil {
+			t.Errorf("Case %d, error running parser: %v", i, err)
+			continue
+		}
+
+		compareValue(t, i, test.path, path)
+		if verbose != nil {
+			compareValue(t, i, test.verbose, *verbose)
+		}
+	}
+}
+
+func TestNewAppFromUsageHelp(t *testing.T) {
+	tests := []struct {
+		cmd    []string
+		golden string
+	}{
+		{cmd: []string{"-h"}, golden: "testdata/docopt-app.golden"},
+		{cmd: []string{"ship", "move", "-h"}, golden: "testdata/docopt-move.golden"},
+		{cmd: []string{"mine", "-h"}, golden: "testdata/docopt-mine.golden"},
+	}
+
+	for i, test := range tests {
+		app, err := libcmd.NewAppFromUsage(navalUsage, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := compareHelpOutput(app, test.cmd, test.golden); err != nil {
+			t.Errorf("Case %d, %v", i, err)
+		}
+	}
+}
+
+func TestNewAppFromUsageErrors(t *testing.T) {
+	tests := []struct {
+		usage    string
+		runs     []string
+		expected string
+	}{
+		{usage: "App.\n", expected: "invalid usage: missing the 'Usage:' section"},
+		{usage: "Usage: app\n  other\n", expected: "invalid usage at line 2: expected the app name 'app', got 'other'"},
+		{usage: "Usage: app [<x>\n", expected: "invalid usage at line 1: unbalanced '['"},
+		{usage: "Usage: app (<x>]\n", expected: "invalid usage at line 1: unbalanced ']'"},
+		{usage: "Usage: app -v | add\n", expected: "invalid usage at line 1: alternatives between commands or operands are not supported"},
+		{usage: "Usage: app <x> | <y>\n", expected: "invalid usage at line 1: alternatives between commands or operands are not supported"},
+		{usage: "Usage: app [add]\n", expected: "invalid usage at line 1: the command 'add' must come before the operands and outside of groups"},
+		{usage: "Usage: app <x> add\n", expected: "invalid usage at line 1: the command 'add' must come before the operands and outside of groups"},
+		{usage: "Usage: app -v...\n", expected: "invalid usage at line 1: repeated options are not supported"},
+		{usage: "Usage: app (<x> <y>)...\n", expected: "invalid usage at line 1: repeated groups are not supported"},
+		{usage: "Usage: app [<x>] <y>\n", expected: "invalid usage at line 1: the required operand 'y' can't follow the optional operand 'x'"},
+		{usage: "Usage: app <x>... [<y>]\n", expected: "invalid usage at line 1: the operand 'y' can't follow the repeating operand 'x'"},
+		{usage: "Usage: app -ab=<x>\n", expected: "invalid usage at line 1: unexpected value on '-ab=<x>'"},
+		{usage: "Usage:\n  app <x>\n  app <y>\n", expected: "invalid usage at line 3: the operands of 'app' differ from the ones of a previous pattern"},
+		{usage: "Usage: app\n\nOptions:\n  --x --y  Two long names.\n", expected: "invalid usage at line 4: unexpected '--y'"},
+		{usage: "Usage: app\n\nOptions:\n  -x  Flag [default: maybe].\n", expected: "invalid usage at line 4: invalid default value 'maybe'"},
+		{usage: "Usage: app add\n\nCommands:\n  remove  Removes.\n", expected: "invalid usage at line 4: unknown command 'remove'"},
+		{usage: "Usage: app add\n", runs: []string{"app remove"}, expected: "run callback registered for unknown command 'app remove'"},
+	}
+
+	for i, test := range tests {
+		runs := make(map[string]libcmd.RunCallback)
+		for _, path := range test.runs {
+			runs[path] = func(*libcmd.Cmd) error { return nil }
+		}
+
+		_, err := libcmd.NewAppFromUsage(test.usage, runs)
+		if err == nil {
+			t.Errorf("Case %d, expected error '%s', got none", i, test.expected)
+			continue
+		}
+
+		compareValue(t, i, test.expected, err.Error())
+	}
+}
+
+func TestNewAppFromUsageDescription(t *testing.T) {
+	usage := `Tool.
+
+Works with the following:
+  files and directories.
+
+Usage: tool <x>
+
+Notes:
+  Ignored.
+`
+
+	app, err := libcmd.NewAppFromUsage(usage, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compareValue(t, 0, "Tool.", app.Brief)
+	compareValue(t, 1, "Works with the following: files and directories.", app.Long)
+}
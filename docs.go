@@ -0,0 +1,211 @@
+package libcmd
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// the data used to render a documentation page
+type docPage struct {
+	*HelpData
+
+	// the path and file of the parent command page; empty on the app page
+	ParentPath string
+	ParentFile string
+
+	// the file of each subcommand page, by name
+	Files map[string]string
+}
+
+// the markdown documentation page; the sections are the same of the help text
+const markdownTemplate = `# {{markdown .Path}}
+{{if .Brief}}
+{{markdown .Brief}}
+{{end}}{{if .ParentPath}}
+Parent command: [{{markdown .ParentPath}}]({{.ParentFile}})
+{{end}}{{if .Usage}}
+## Usage
+
+` + "```" + `
+{{.Usage}}
+` + "```" + `
+{{end}}{{if .Long}}
+{{markdown .Long}}
+{{end}}{{if .Options}}
+## Options
+{{range .Options}}{{if .Name}}
+### {{markdown .Name}}
+{{end}}
+{{range .Options}}- ` + "`{{.Header}}`" + `: {{markdown .Description}}
+{{end}}{{end}}{{end}}{{if .Commands}}
+## Commands
+{{range .Commands}}{{if .Category}}
+### {{markdown .Category}}
+{{end}}
+{{range .Commands}}- [{{markdown .Name}}]({{index $.Files .Name}}){{if .Aliases}} (aliases: {{markdown (join .Aliases ", ")}}){{end}}{{if .Brief}}: {{markdown .Brief}}{{end}}
+{{end}}{{end}}{{end}}{{if .Topics}}
+## Help Topics
+{{range .Topics}}
+### {{markdown .Name}}
+{{if .Brief}}
+{{markdown .Brief}}
+{{end}}{{if .Text}}
+{{markdown .Text}}
+{{end}}{{end}}{{end}}{{if .Examples}}
+## Examples
+{{range .Examples}}{{if .Description}}
+{{markdown .Description}}
+{{end}}
+` + "```" + `
+{{.Command}}
+` + "```" + `
+{{end}}{{end}}`
+
+// the standalone HTML documentation page
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Path}}</title>
+</head>
+<body>
+<h1>{{.Path}}</h1>
+{{if .Brief}}<p>{{.Brief}}</p>
+{{end}}{{if .ParentPath}}<p>Parent command: <a href="{{.ParentFile}}">{{.ParentPath}}</a></p>
+{{end}}{{if .Usage}}<h2>Usage</h2>
+<pre>{{.Usage}}</pre>
+{{end}}{{if .Long}}{{range paragraphs .Long}}<p>{{.}}</p>
+{{end}}{{end}}{{if .Options}}<h2>Options</h2>
+{{range .Options}}{{if .Name}}<h3>{{.Name}}</h3>
+{{end}}<dl>
+{{range .Options}}<dt><code>{{.Header}}</code></dt>
+<dd>{{.Description}}</dd>
+{{end}}</dl>
+{{end}}{{end}}{{if .Commands}}<h2>Commands</h2>
+{{range .Commands}}{{if .Category}}<h3>{{.Category}}</h3>
+{{end}}<dl>
+{{range .Commands}}<dt><a href="{{index $.Files .Name}}">{{.Name}}</a>{{if .Aliases}} (aliases: {{join .Aliases ", "}}){{end}}</dt>
+<dd>{{.Brief}}</dd>
+{{end}}</dl>
+{{end}}{{end}}{{if .Topics}}<h2>Help Topics</h2>
+{{range .Topics}}<h3>{{.Name}}</h3>
+{{if .Brief}}<p>{{.Brief}}</p>
+{{end}}{{range paragraphs .Text}}<p>{{.}}</p>
+{{end}}{{end}}{{end}}{{if .Examples}}<h2>Examples</h2>
+{{range .Examples}}{{if .Description}}<p>{{.Description}}</p>
+{{end}}<pre>{{.Command}}</pre>
+{{end}}{{end}}</body>
+</html>
+`
+
+// escapes the characters with a meaning in Markdown (or in
+// the HTML accepted by it) on a text outside of code blocks
+var markdownEscaper = strings.NewReplacer(
+	"&", "&amp;", "<", "&lt;", ">", "&gt;",
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`,
+	"[", `\[`, "]", `\]`, "#", `\#`, "|", `\|`,
+)
+
+// functions available to the documentation templates
+var docFuncs = map[string]interface{}{
+	"join":     strings.Join,
+	"markdown": markdownEscaper.Replace,
+	"paragraphs": func(text string) []string {
+		var paragraphs []string
+		for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+			if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+				paragraphs = append(paragraphs, paragraph)
+			}
+		}
+
+		return paragraphs
+	},
+}
+
+var (
+	markdownDoc = template.Must(template.New("markdown").Funcs(docFuncs).Parse(markdownTemplate))
+	htmlDoc     = htmltemplate.Must(htmltemplate.New("html").Funcs(docFuncs).Parse(htmlTemplate))
+)
+
+// the templates that render the documentation pages
+type docTemplate interface {
+	Execute(writer io.Writer, data interface{}) error
+}
+
+// PrintMarkdown prints the documentation page of the command, in Markdown,
+// to the specified writer. The page has the same sections of the help text,
+// with links to the pages of the parent command and of the subcommands, named
+// as in WriteMarkdown.
+func (cmd *Cmd) PrintMarkdown(writer io.Writer) error {
+	return cmd.printDoc(markdownDoc, ".md", writer)
+}
+
+// PrintHTML prints the documentation page of the command, as a standalone
+// HTML document, to the specified writer. Besides the format, it works exactly
+// like PrintMarkdown.
+func (cmd *Cmd) PrintHTML(writer io.Writer) error {
+	return cmd.printDoc(htmlDoc, ".html", writer)
+}
+
+// WriteMarkdown writes the Markdown documentation page (see PrintMarkdown) of
+// the app and of every visible subcommand, recursively, to the specified
+// directory. Each command path has it's own page, with the names separated
+// by '-', like 'app-sub.md'.
+//
+// The subcommands are configured along the way (see Command).
+func (app *App) WriteMarkdown(dir string) error {
+	return app.writeDocs(markdownDoc, ".md", dir)
+}
+
+// WriteHTML writes the HTML documentation page (see PrintHTML) of the app
+// and of every visible subcommand, recursively, to the specified directory.
+// The pages are named like in WriteMarkdown, with the '.html' extension.
+func (app *App) WriteHTML(dir string) error {
+	return app.writeDocs(htmlDoc, ".html", dir)
+}
+
+func (cmd *Cmd) printDoc(tmpl docTemplate, ext string, writer io.Writer) error {
+	cmd.configure()
+	cmd.setupHelp()
+
+	page := docPage{HelpData: cmd.HelpData(), Files: make(map[string]string)}
+
+	if cmd.parentCmd != nil {
+		page.ParentPath = cmd.parentCmd.path()
+		page.ParentFile = pageName(cmd.parentCmd) + ext
+	}
+
+	for _, c := range visibleCommands(cmd) {
+		page.Files[c.Name] = pageName(c) + ext
+	}
+
+	return tmpl.Execute(writer, page)
+}
+
+func (cmd *Cmd) writeDocs(tmpl docTemplate, ext string, dir string) error {
+	file, err := os.Create(filepath.Join(dir, pageName(cmd)+ext))
+	if err != nil {
+		return err
+	}
+
+	err = cmd.printDoc(tmpl, ext, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, c := range visibleCommands(cmd) {
+		if err := c.writeDocs(tmpl, ext, dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package libcmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func TestDocs(t *testing.T) {
+	tests := []struct {
+		sub  bool
+		html bool
+		file string
+	}{
+		{file: "testdata/docs-app.md.golden"},
+		{sub: true, file: "testdata/docs-copy.md.golden"},
+		{html: true, file: "testdata/docs-app.html.golden"},
+		{sub: true, html: true, file: "testdata/docs-copy.html.golden"},
+	}
+
+	for i, test := range tests {
+		bytes, err := ioutil.ReadFile(test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		app, sub := manApp()
+		app.HelpTopic("filters", "The filter syntax.", "Filters are <key>=<value> pairs.\n\nSeveral filters can be used.")
+
+		cmd := app.Cmd
+		if test.sub {
+			cmd = sub
+		}
+
+		var b strings.Builder
+		if test.html {
+			err = cmd.PrintHTML(&b)
+		} else {
+			err = cmd.PrintMarkdown(&b)
+		}
+
+		if err != nil {
+			t.Errorf("Case %d, error printing the page: %v", i, err)
+			continue
+		}
+
+		compareValue(t, i, string(bytes), b.String())
+	}
+}
+
+func TestWriteDocs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "libcmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	app, _ := manApp()
+	if err := app.WriteMarkdown(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.WriteHTML(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	sort.Strings(names)
+
+	compareValue(t, 0, "app-copy.html,app-copy.md,app-help.html,app-help.md,app.html,app.md", strings.Join(names, ","))
+}
+
+func TestMarkdownEscape(t *testing.T) {
+	app := libcmd.NewApp("app", "Uses *stars*, _underscores_ & [brackets].")
+	app.String("pattern", 'p', "", "A `glob`, like a|b or #1.")
+
+	var b strings.Builder
+	if err := app.PrintMarkdown(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	text := b.String()
+	for i, expected := range []string{
+		"\nUses \\*stars\\*, \\_underscores\\_ &amp; \\[brackets\\].\n",
+		": A \\`glob\\`, like a\\|b or \\#1.\n",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Case %d, expected '%s' on:\n%s", i, expected, text)
+		}
+	}
+}
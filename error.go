@@ -1,9 +1,16 @@
 package libcmd
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 )
 
+// ErrStop can be returned by an OptCallback to stop the processing of
+// the command line. The parsing ends without errors, but no subcommand or
+// Run callback is executed.
+var ErrStop = errors.New("processing stopped")
+
 // parsing: generic parser error
 type parserError struct {
 	arg string
@@ -70,6 +77,69 @@ func (e operandRequiredErr) Error() string {
 	return fmt.Sprintf("wrong number of operands, at least %d required (got %d)", e.required, e.got)
 }
 
+// parsing: usage of a removed argument or command
+type removedErr struct {
+	name        string
+	deprecation *Deprecation
+}
+
+func (e removedErr) Error() string {
+	s := fmt.Sprintf("'%s' was removed in version %s", e.name, e.deprecation.RemovedIn)
+
+	if e.deprecation.Replacement != "" {
+		s += fmt.Sprintf(", use '%s' instead", e.deprecation.Replacement)
+	}
+
+	return s
+}
+
+// parsing: unknown command on a command path
+type unknownCommandErr struct {
+	name        string
+	path        string
+	suggestions []string
+}
+
+func (e unknownCommandErr) Error() string {
+	s := fmt.Sprintf("unknown command '%s' for '%s'", e.name, e.path)
+
+	if len(e.suggestions) > 0 {
+		s += fmt.Sprintf(" (did you mean '%s'?)", strings.Join(e.suggestions, "' or '"))
+	}
+
+	return s
+}
+
+// examples: the example does not work
+type exampleErr struct {
+	example string
+	err     error
+}
+
+func (e exampleErr) Error() string {
+	return fmt.Sprintf("invalid example '%s': %v", e.example, e.err)
+}
+
+// spec: invalid element of a spec
+type specErr struct {
+	path string
+	msg  string
+}
+
+func (e specErr) Error() string {
+	return fmt.Sprintf("invalid spec at %s: %s", e.path, e.msg)
+}
+
+// usage: invalid docopt-style usage text
+type usageErr struct {
+	line int
+	msg  string
+}
+
+func (e usageErr) Error() string {
+	return fmt.Sprintf("invalid usage at line %d: %s", e.line, e.msg)
+}
+
 // IsParserErr returns true is the error is an error
 // generated by the parsing process itself.
 func IsParserErr(err error) bool {
@@ -93,6 +163,12 @@ func IsParserErr(err error) bool {
 	case operandRequiredErr:
 		return true
 
+	case removedErr:
+		return true
+
+	case unknownCommandErr:
+		return true
+
 	default:
 		return false
 	}
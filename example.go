@@ -0,0 +1,134 @@
+package libcmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Example documents an usage example of a command.
+type Example struct {
+	// The full command line, as typed by the user, including
+	// the program name (e. g. 'app copy -r src dst')
+	Command string
+
+	// What the example does
+	Description string
+}
+
+// CheckExamples parses the examples of the command and all of its
+// subcommands, returning an error if any of them fails to parse or
+// selects a different command than the one that documents it. It is meant
+// to be used in tests, to catch stale examples.
+//
+// The parsing happens exactly like in App.ParseArgs, except that the Match
+// and Run callbacks and the Err handlers are never executed. The variables
+// bound to the arguments are restored after the check. The subcommands are
+// configured along the way (see Command).
+func (cmd *Cmd) CheckExamples() error {
+	root := cmd
+	for root.parentCmd != nil {
+		root = root.parentCmd
+	}
+
+	defer root.snapshot()()
+	return cmd.checkExamples(root)
+}
+
+func (cmd *Cmd) checkExamples(root *Cmd) error {
+	for _, example := range cmd.Examples {
+		args := splitCommandLine(example.Command)
+		if len(args) == 0 {
+			return exampleErr{example: example.Command, err: fmt.Errorf("empty command line")}
+		}
+
+		// the first word is the program name
+		leaf, err := root.dispatch(args[1:], true)
+		if err == nil && leaf != cmd {
+			err = fmt.Errorf("runs '%s' instead of '%s'", leaf.path(), cmd.path())
+		}
+
+		if err == nil {
+			err = leaf.checkOperands(leaf.EffectiveOptions().StrictOperands)
+		}
+
+		if err != nil {
+			return exampleErr{example: example.Command, err: err}
+		}
+	}
+
+	for _, c := range cmd.commands {
+		if err := c.checkExamples(root); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// configures the command and all of it's subcommands, returning a function
+// that restores the state of the parser (the argument values and operands)
+func (cmd *Cmd) snapshot() func() {
+	cmd.configure()
+	cmd.setupHelp()
+
+	args := cmd.args
+	restores := make([]func(), 0, len(cmd.optentries)+len(cmd.commands))
+
+	for _, entry := range cmd.optentries {
+		restores = append(restores, entry.val.snapshot())
+	}
+
+	for _, c := range cmd.commands {
+		restores = append(restores, c.snapshot())
+	}
+
+	return func() {
+		cmd.args = args
+
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
+
+// splits a command line in words, like a (very) simplified shell.
+// Words are separated by spaces, and single or double quotes can
+// be used to group words
+func splitCommandLine(line string) []string {
+	var words []string
+	var current strings.Builder
+	var quote rune
+	var inWord bool
+
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+
+		case quote != 0:
+			current.WriteRune(r)
+
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, current.String())
+	}
+
+	return words
+}
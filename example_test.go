@@ -0,0 +1,102 @@
+package libcmd_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func TestCheckExamples(t *testing.T) {
+	tests := []struct {
+		app       []libcmd.Example
+		copy      []libcmd.Example
+		expectErr string
+	}{
+		{},
+		{
+			app:  []libcmd.Example{{Command: "app -v"}, {Command: "app"}},
+			copy: []libcmd.Example{{Command: "app copy a b"}, {Command: `app -v copy --mode=fast "a file" 'other file'`}, {Command: "app cp -r a b"}},
+		},
+		{app: []libcmd.Example{{Command: "app --verbose"}}, expectErr: "invalid example 'app --verbose': unknown argument: --verbose"},
+		{app: []libcmd.Example{{Command: "app copy"}}, expectErr: "invalid example 'app copy': runs 'app copy' instead of 'app'"},
+		{app: []libcmd.Example{{Command: ""}}, expectErr: "empty command line"},
+		{copy: []libcmd.Example{{Command: "app copy a"}}, expectErr: "wrong number of operands"},
+		{copy: []libcmd.Example{{Command: "app copy --mode=slow a b"}}, expectErr: "'slow' is not a valid value"},
+		{copy: []libcmd.Example{{Command: "app a b"}}, expectErr: "runs 'app' instead of 'app copy'"},
+	}
+
+	for i, test := range tests {
+		var run bool
+		app := libcmd.NewApp("app", "")
+		app.Options.HelpOutput = ioutil.Discard
+		app.Examples = test.app
+		app.Bool("", 'v', false, "")
+
+		// the handlers would hide the errors of the stale examples
+		handle := func(error) error {
+			t.Errorf("Case %d, checking the examples should not run the error handlers", i)
+			return nil
+		}
+		app.Err(handle)
+
+		app.Command("copy", "", func(cmd *libcmd.Cmd) {
+			cmd.Options.StrictOperands = true
+			cmd.Err(handle)
+			cmd.Examples = test.copy
+			cmd.Bool("", 'r', false, "")
+			cmd.Choice([]string{"fast", "safe"}, "mode", 0, "safe", "")
+			cmd.AddOperand("src", "")
+			cmd.AddOperand("dst", "")
+
+			cmd.Run(func(*libcmd.Cmd) error {
+				run = true
+				return nil
+			})
+		}).Aliases = []string{"cp"}
+
+		err := app.CheckExamples()
+		if test.expectErr == "" && err != nil {
+			t.Errorf("Case %d, unexpected error: %v", i, err)
+		} else if test.expectErr != "" && (err == nil || !strings.Contains(err.Error(), test.expectErr)) {
+			t.Errorf("Case %d, expected error '%s', but got '%v'", i, test.expectErr, err)
+		}
+
+		if run {
+			t.Errorf("Case %d, checking the examples should not run the commands", i)
+		}
+	}
+}
+
+func TestCheckExamplesRestoresValues(t *testing.T) {
+	app := libcmd.NewApp("app", "")
+	app.Examples = []libcmd.Example{{Command: "app -v --name=example"}}
+
+	verbose := app.Bool("", 'v', false, "")
+	name := new(string)
+	*name = "bound"
+	app.StringP(name, "name", 0, "", "")
+
+	var level *int
+	app.Command("sub", "", func(cmd *libcmd.Cmd) {
+		cmd.Examples = []libcmd.Example{{Command: "app sub --level 9 a"}}
+		level = cmd.Int("level", 0, 1, "")
+	})
+
+	if err := app.CheckExamples(); err != nil {
+		t.Fatal(err)
+	}
+
+	compareValue(t, 0, false, *verbose)
+	compareValue(t, 0, "bound", *name)
+	compareValue(t, 0, 0, *level)
+
+	if err := app.ParseArgs([]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	compareValue(t, 0, false, *verbose)
+	compareValue(t, 0, "bound", *name)
+	compareArgs(t, 0, []string{}, app.Args())
+}
@@ -1,11 +1,26 @@
 package libcmd
 
-func (cmd *Cmd) getOptVal(name string) interface{} {
+func (cmd *Cmd) getOpt(name string) *optEntry {
 	if opt := cmd.findOpt("-" + name); opt != nil {
-		return opt.val.raw
+		return opt
+	}
+
+	if opt := cmd.findOpt("--" + name); opt != nil {
+		return opt
 	}
 
-	return cmd.findOpt("--" + name).val.raw
+	panic("unknown argument: " + name)
+}
+
+func (cmd *Cmd) getOptVal(name string) interface{} {
+	return cmd.getOpt(name).val.raw
+}
+
+// Opt returns the extra settings of the argument 'name' (you can use either
+// the short or long name), allowing them to be changed.
+// If the argument does not exist, this routine panics.
+func (cmd *Cmd) Opt(name string) *Opt {
+	return &cmd.getOpt(name).Opt
 }
 
 // GetString returns the string pointer used as value
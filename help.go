@@ -5,9 +5,25 @@ import (
 	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 )
 
+const (
+	// the default width of the help text, when the terminal
+	// width is unknown
+	helpDefaultWidth = 80
+
+	// limits of the width of the first column on the
+	// options and commands list
+	helpMinColumn = 24
+	helpMaxColumn = 40
+
+	// the minimum space reserved to the descriptions, even
+	// on very narrow terminals
+	helpMinText = 20
+)
+
 // Help prints the help text in the stdout.
 // Normally, this method is not manually called, since it will be
 // automatically executed on the following scenarios:
@@ -28,13 +44,16 @@ import (
 // Last, but not least, if you need to override the actual text of the help, set
 // the OnHelp field of the App options instance.
 func (cmd *Cmd) Help() {
-	output := cmd.Options.HelpOutput
+	cmd.PrintHelp(cmd.helpOutput())
+}
 
-	if output == nil {
-		output = os.Stdout
+// the writer used to print the help text
+func (cmd *Cmd) helpOutput() io.Writer {
+	if output := cmd.EffectiveOptions().HelpOutput; output != nil {
+		return output
 	}
 
-	cmd.PrintHelp(output)
+	return os.Stdout
 }
 
 // PrintHelp prints the help text to the specified writer.
@@ -42,53 +61,249 @@ func (cmd *Cmd) Help() {
 func (cmd *Cmd) PrintHelp(writer io.Writer) {
 	handler := automaticHelp
 
-	if cmd.Options.OnHelp != nil {
-		handler = cmd.Options.OnHelp
+	if onHelp := cmd.EffectiveOptions().OnHelp; onHelp != nil {
+		handler = onHelp
 	}
 
 	handler(cmd, writer)
 }
 
-func automaticHelp(cmd *Cmd, writer io.Writer) {
-	printHelpBrief(cmd, writer)
-	printHelpUsage(cmd, writer)
-	printHelpLong(cmd, writer)
-	printHelpOptions(cmd, writer)
-	printHelpCommands(cmd, writer)
+// PrintHelpAll prints the help text of the command and of every
+// visible subcommand, recursively, to the specified writer.
+//
+// The subcommands are configured along the way (see Command).
+func (cmd *Cmd) PrintHelpAll(writer io.Writer) {
+	cmd.setupHelp()
+	cmd.PrintHelp(writer)
+
+	for _, c := range visibleCommands(cmd) {
+		c.configure()
+		fmt.Fprintln(writer)
+		c.PrintHelpAll(writer)
+	}
 }
 
-func printHelpBrief(cmd *Cmd, writer io.Writer) {
-	fullname := strings.TrimSpace(cmd.breadcrumbs + " " + cmd.Name)
+// PrintTree prints a compact view of the command and all of it's
+// visible subcommands, recursively, with their aliases and brief
+// descriptions.
+//
+// The subcommands are configured along the way (see Command).
+func (cmd *Cmd) PrintTree(writer io.Writer) {
+	var headers, briefs []string
+
+	var walk func(c *Cmd, depth int)
+	walk = func(c *Cmd, depth int) {
+		for _, sub := range visibleCommands(c) {
+			sub.configure()
+
+			headers = append(headers, strings.Repeat("  ", depth)+commandHeader(sub))
+			briefs = append(briefs, commandBrief(sub))
+			walk(sub, depth+1)
+		}
+	}
+
+	cmd.setupHelp()
+	walk(cmd, 0)
 
-	if cmd.Brief != "" {
-		fmt.Fprintf(writer, "%s - %s\n", fullname, cmd.Brief)
+	if brief := commandBrief(cmd); brief != "" {
+		fmt.Fprintf(writer, "%s - %s\n", cmd.path(), brief)
 	} else {
-		fmt.Fprintln(writer, fullname)
+		fmt.Fprintln(writer, cmd.path())
+	}
+
+	width := helpWidth(cmd)
+	column := helpColumn(headers, 0, width)
+
+	for i := range headers {
+		fmt.Fprint(writer, helpItem(headers[i], column, 3, briefs[i], width))
+	}
+}
+
+// prints a parsing error, along with the usage line and a hint
+// on how to get the full help text of the command
+func (cmd *Cmd) printUsageError(err error) {
+	writer := cmd.EffectiveOptions().ErrorOutput
+	if writer == nil {
+		writer = os.Stderr
+	}
+
+	cmd.setupHelp()
+	fmt.Fprintf(writer, "error: %v\n", err)
+
+	if usage := helpUsage(cmd); usage != "" {
+		fmt.Fprintf(writer, "\nUSAGE: %s\n", usage)
+	}
+
+	if hint := cmd.helpHint(); hint != "" {
+		fmt.Fprintf(writer, "\nRun '%s' for more information.\n", hint)
+	}
+}
+
+// the command line that shows the help text of the command, using
+// the help flag or the help command; empty if there is none
+func (cmd *Cmd) helpHint() string {
+	if cmd.longopt["--help"] != nil {
+		return cmd.path() + " --help"
+	}
+
+	root := cmd
+	names := make([]string, 0)
+	for root.parentCmd != nil {
+		names = append([]string{root.Name}, names...)
+		root = root.parentCmd
+	}
+
+	if root.EffectiveOptions().HelpCommand {
+		return strings.TrimSpace(root.Name + " help " + strings.Join(names, " "))
 	}
+
+	return ""
+}
+
+func (cmd *Cmd) setupHelpCommand() {
+	c := cmd.Command("help", "Shows the help of a command.", nil)
+	c.AddOperand("command", "*")
+	c.Run(func(c *Cmd) error {
+		if args := c.Args(); len(args) == 1 && cmd.findCommand(args[0]) == nil {
+			if topic := cmd.findTopic(args[0]); topic != nil {
+				cmd.printTopic(topic, cmd.helpOutput())
+				return nil
+			}
+		}
+
+		target, err := cmd.resolvePath(c.Args())
+		if err != nil {
+			return err
+		}
+
+		target.Help()
+		return nil
+	})
+}
+
+// finds the subcommand by following the path of command names
+// (e. g. 'sub deep'), preparing it to show the help text
+func (cmd *Cmd) resolvePath(names []string) (*Cmd, error) {
+	target := cmd
+
+	for _, name := range names {
+		c := target.findCommand(name)
+		if c == nil {
+			return nil, unknownCommandErr{name: name, path: target.path(), suggestions: target.suggestCommands(name)}
+		}
+
+		c.configure()
+		target = c
+	}
+
+	target.setupHelp()
+	return target, nil
 }
 
-func printHelpLong(cmd *Cmd, writer io.Writer) {
-	if cmd.Long != "" {
-		fmt.Fprintf(writer, "\n%s\n", cmd.Long)
+// the names of visible subcommands (or aliases) and help topics
+// similar to the specified name, from the most to the least similar
+func (cmd *Cmd) suggestCommands(name string) []string {
+	candidates := make([][]string, 0)
+
+	for _, c := range visibleCommands(cmd) {
+		candidates = append(candidates, append([]string{c.Name}, c.Aliases...))
+	}
+
+	for _, topic := range cmd.topics {
+		candidates = append(candidates, []string{topic.name})
 	}
+
+	return similarNames(name, candidates)
+}
+
+func automaticHelp(cmd *Cmd, writer io.Writer) {
+	renderHelp(defaultHelpTemplate, cmd, writer)
 }
 
-func printHelpUsage(cmd *Cmd, writer io.Writer) {
+// the width of the help text, taken from the options, the
+// COLUMNS environment variable or a sensible default, in this order
+func helpWidth(cmd *Cmd) int {
+	if width := cmd.EffectiveOptions().HelpWidth; width > 0 {
+		return width
+	}
+
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+
+	return helpDefaultWidth
+}
+
+// the width of the first column of a list, based on the largest header.
+// Headers that are too large for the terminal width (or larger than
+// helpMaxColumn) are ignored; they are printed on a line of their own
+func helpColumn(headers []string, min, width int) int {
+	max := helpMaxColumn
+	if width/2 < max {
+		max = width / 2
+	}
+
+	column := 0
+	for _, header := range headers {
+		if w := displayWidth(header); w > column && w <= max {
+			column = w
+		}
+	}
+
+	if column < min {
+		column = min
+	}
+
+	return column
+}
+
+// formats a list item, with the header on the first column and the text,
+// wrapped, on the second. When the header does not fit the first column,
+// the text starts on the next line
+func helpItem(header string, column, gap int, text string, width int) string {
+	const indent = "  "
+
+	textColumn := len(indent) + column + gap
+	textWidth := width - textColumn
+	if textWidth < helpMinText {
+		textWidth = helpMinText
+	}
+
+	var b strings.Builder
+	lines := wrapText(text, textWidth)
+	first := indent + padRight(header, column) + strings.Repeat(" ", gap)
+
+	if displayWidth(header) > column {
+		b.WriteString(indent + header + "\n")
+		first = strings.Repeat(" ", textColumn)
+	}
+
+	b.WriteString(strings.TrimRight(first+lines[0], " ") + "\n")
+
+	for _, line := range lines[1:] {
+		b.WriteString(strings.TrimRight(strings.Repeat(" ", textColumn)+line, " ") + "\n")
+	}
+
+	return b.String()
+}
+
+// the usage line of the command; empty if the
+// usage line should not be printed
+func helpUsage(cmd *Cmd) string {
 	// do not print usage line
 	if cmd.Usage == "-" {
-		return
+		return ""
 	}
 
 	// use a custom usage line
 	if cmd.Usage != "" {
-		fmt.Fprintf(writer, "\nUSAGE: %s\n", cmd.Usage)
-		return
+		return cmd.Usage
 	}
 
 	// compute a usage line
-	usage := strings.TrimSpace(cmd.breadcrumbs + " " + cmd.Name)
+	usage := cmd.path()
 
-	if len(cmd.optentries) > 0 {
+	if len(visibleOptions(cmd)) > 0 {
 		usage += " [OPTIONS...]"
 	}
 
@@ -110,12 +325,12 @@ func printHelpUsage(cmd *Cmd, writer io.Writer) {
 		params = operands
 	}
 
-	fmt.Fprintf(writer, "\nUSAGE: %s\n", strings.TrimSpace(usage+" "+params))
+	return strings.TrimSpace(usage + " " + params)
 }
 
 func getHelpOperands(cmd *Cmd) string {
 	if len(cmd.operands) == 0 {
-		if cmd.Options.StrictOperands || len(cmd.commands) > 0 {
+		if cmd.EffectiveOptions().StrictOperands || len(cmd.commands) > 0 {
 			return ""
 		}
 
@@ -142,50 +357,153 @@ func getHelpOperands(cmd *Cmd) string {
 }
 
 func getHelpCommands(cmd *Cmd) string {
-	if len(cmd.commands) > 0 {
+	if len(visibleCommands(cmd)) > 0 {
 		return "COMMAND"
 	}
 
 	return ""
 }
 
-func printHelpOptions(cmd *Cmd, writer io.Writer) {
-	if len(cmd.optentries) == 0 {
-		return
+// the options that should be documented, i. e. the non-hidden ones
+func visibleOptions(cmd *Cmd) []*optEntry {
+	entries := make([]*optEntry, 0, len(cmd.optentries))
+
+	for _, entry := range cmd.optentries {
+		if !entry.Hidden {
+			entries = append(entries, entry)
+		}
 	}
-	sort.Slice(cmd.optentries, func(i, j int) bool {
-		return cmd.optentries[i].helpHeader() < cmd.optentries[j].helpHeader()
+
+	return entries
+}
+
+// the subcommands that should be documented, sorted by name
+func visibleCommands(cmd *Cmd) []*Cmd {
+	commands := make([]*Cmd, 0, len(cmd.commands))
+
+	for _, c := range cmd.commands {
+		if !c.Hidden {
+			commands = append(commands, c)
+		}
+	}
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
 	})
 
-	fmt.Fprintf(writer, "\nOptions:\n")
+	return commands
+}
 
-	for _, entry := range cmd.optentries {
-		fmt.Fprintf(writer, "  %-24s  %s\n", entry.helpHeader(), entry.helpExplain())
+// a set of subcommands of the same category
+type commandGroup struct {
+	category string
+	commands []*Cmd
+}
+
+// the visible subcommands, grouped by category. The commands without
+// a category always come first, followed by the categories listed in
+// Options.CategoryOrder and then the remaining ones, sorted by name
+func commandGroups(cmd *Cmd) []commandGroup {
+	byCategory := make(map[string][]*Cmd)
+	categories := make([]string, 0)
+
+	for _, c := range visibleCommands(cmd) {
+		if _, ok := byCategory[c.Category]; !ok && c.Category != "" {
+			categories = append(categories, c.Category)
+		}
+
+		byCategory[c.Category] = append(byCategory[c.Category], c)
 	}
+
+	position := make(map[string]int)
+	for i, category := range cmd.EffectiveOptions().CategoryOrder {
+		if _, ok := position[category]; !ok {
+			position[category] = i
+		}
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		pi, iok := position[categories[i]]
+		pj, jok := position[categories[j]]
+
+		switch {
+		case iok && jok:
+			return pi < pj
+		case iok || jok:
+			return iok
+		default:
+			return categories[i] < categories[j]
+		}
+	})
+
+	groups := make([]commandGroup, 0, len(categories)+1)
+	if commands, ok := byCategory[""]; ok {
+		groups = append(groups, commandGroup{commands: commands})
+	}
+
+	for _, category := range categories {
+		groups = append(groups, commandGroup{category: category, commands: byCategory[category]})
+	}
+
+	return groups
 }
 
-func printHelpCommands(cmd *Cmd, writer io.Writer) {
-	if len(cmd.commands) == 0 {
-		return
+// the command name, followed by it's aliases
+func commandHeader(cmd *Cmd) string {
+	return strings.Join(append([]string{cmd.Name}, cmd.Aliases...), ", ")
+}
+
+// the command brief, with the deprecation notice
+func commandBrief(cmd *Cmd) string {
+	if cmd.Deprecated != nil {
+		return strings.TrimSpace(cmd.Brief + " (deprecated)")
 	}
 
-	largest := 0
-	keys := make([]string, 0, len(cmd.commands))
+	return cmd.Brief
+}
+
+// a set of options of the same group
+type optionGroup struct {
+	name    string
+	entries []*optEntry
+}
+
+// the visible options, split by group. The options without a group
+// always come first, followed by the other groups in the order they
+// were declared. Inside each group, the options are sorted by their help
+// header, unless Options.KeepOptionOrder is set
+func optionGroups(cmd *Cmd) []optionGroup {
+	declared := visibleOptions(cmd)
+	entries := make([]*optEntry, len(declared))
+	copy(entries, declared)
+
+	if !cmd.EffectiveOptions().KeepOptionOrder {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].helpHeader() < entries[j].helpHeader()
+		})
+	}
 
-	for k := range cmd.commands {
-		keys = append(keys, k)
+	names := []string{""}
+	byGroup := make(map[string][]*optEntry)
 
-		if len(k) > largest {
-			largest = len(k)
+	for _, entry := range declared {
+		if _, ok := byGroup[entry.Group]; !ok && entry.Group != "" {
+			names = append(names, entry.Group)
 		}
+
+		byGroup[entry.Group] = nil
 	}
 
-	sort.Strings(keys)
-	fmt.Fprintf(writer, "\nCommands:\n")
+	for _, entry := range entries {
+		byGroup[entry.Group] = append(byGroup[entry.Group], entry)
+	}
 
-	for _, k := range keys {
-		c := cmd.commands[k]
-		fmt.Fprintf(writer, "  %-*s   %s\n", largest, c.Name, c.Brief)
+	groups := make([]optionGroup, 0, len(names))
+	for _, name := range names {
+		if len(byGroup[name]) > 0 {
+			groups = append(groups, optionGroup{name: name, entries: byGroup[name]})
+		}
 	}
 
+	return groups
 }
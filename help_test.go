@@ -3,12 +3,20 @@ package libcmd_test
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/ibraimgm/libcmd"
 )
 
+// the width of the help text comes from the environment, so it
+// is cleared to give the same results everywhere
+func TestMain(m *testing.M) {
+	os.Unsetenv("COLUMNS")
+	os.Exit(m.Run())
+}
+
 func compareHelpOutput(app *libcmd.App, args []string, goldenfile string) error {
 	bytes, err := ioutil.ReadFile(goldenfile)
 	if err != nil {
@@ -322,3 +330,394 @@ func TestHelpChoiceTemplate(t *testing.T) {
 		}
 	}
 }
+
+func TestCommandAliasHidden(t *testing.T) {
+	app := libcmd.NewApp("app", "some brief description")
+
+	app.String("astring", 's', "", "Sets a string value.")
+	app.Int("secret", 0, 0, "A hidden value.")
+	app.Opt("secret").Hidden = true
+
+	app.Command("add", "Sums two numbers.", nil)
+	app.Command("remove", "Removes a number.", nil).Aliases = []string{"rm", "del"}
+	app.Command("debug", "Debug the app.", nil).Hidden = true
+
+	if err := compareHelpOutput(app, []string{"-h"}, "testdata/alias-hidden.golden"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCommandCategory(t *testing.T) {
+	app := libcmd.NewApp("app", "some brief description")
+	app.Options.CategoryOrder = []string{"Management", "Debugging"}
+
+	app.Command("version", "Shows the version.", nil)
+	app.Command("trace", "Traces the execution.", nil).Category = "Debugging"
+	app.Command("create", "Creates a container.", nil).Category = "Management"
+	app.Command("remove", "Removes a container.", nil).Category = "Management"
+	app.Command("login", "Logs in.", nil).Category = "Account"
+	app.Command("inspect", "Inspects the state.", nil).Category = "Debugging"
+	app.Command("logout", "Logs out.", nil).Category = "Account"
+	app.Command("help-me", "Help me.", nil).Category = "Account"
+
+	if err := compareHelpOutput(app, []string{"-h"}, "testdata/category.golden"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOptionGroups(t *testing.T) {
+	tests := []struct {
+		keepOrder bool
+		file      string
+	}{
+		{file: "testdata/group-sorted.golden"},
+		{keepOrder: true, file: "testdata/group-declared.golden"},
+	}
+
+	for i, test := range tests {
+		app := libcmd.NewApp("app", "some brief description")
+		app.Options.KeepOptionOrder = test.keepOrder
+
+		app.String("output", 'o', "", "Sets the output file.")
+		app.String("host", 0, "localhost", "Sets the host.")
+		app.Int("port", 'p', 80, "Sets the port.")
+		app.Bool("verbose", 'v', false, "Shows more information.")
+		app.String("format", 'f', "", "Sets the output format.")
+		app.Opt("host").Group = "Network"
+		app.Opt("port").Group = "Network"
+		app.Opt("output").Group = "Output"
+		app.Opt("format").Group = "Output"
+
+		if err := compareHelpOutput(app, []string{"-h"}, test.file); err != nil {
+			t.Errorf("Case %d, %v", i, err)
+		}
+
+		if err := compareHelpOutput(app, []string{"-h"}, test.file); err != nil {
+			t.Errorf("Case %d, printing the help twice should give the same result: %v", i, err)
+		}
+	}
+}
+
+func TestHelpWrap(t *testing.T) {
+	tests := []struct {
+		width   int
+		columns string
+		file    string
+	}{
+		{width: 50, file: "testdata/wrap-50.golden"},
+		{columns: "50", file: "testdata/wrap-50.golden"},
+		{width: 50, columns: "200", file: "testdata/wrap-50.golden"},
+		{columns: "", file: "testdata/wrap-default.golden"},
+	}
+
+	columns, hasColumns := os.LookupEnv("COLUMNS")
+	defer func() {
+		if hasColumns {
+			os.Setenv("COLUMNS", columns)
+		} else {
+			os.Unsetenv("COLUMNS")
+		}
+	}()
+
+	for i, test := range tests {
+		os.Setenv("COLUMNS", test.columns)
+
+		app := libcmd.NewApp("app", "some brief description")
+		app.Options.HelpWidth = test.width
+		app.Long = "This is a very long description, that does not fit in a single line and must be wrapped.\n\n  Indented lines keep their indentation when wrapped."
+
+		app.String("descrição", 'd', "", "Uma descrição com acentuação, que deve ser alinhada corretamente.")
+		app.String("名前", 'n', "", "名前を設定します。")
+		app.Int("a-very-long-option-name-that-breaks-the-column", 0, 0, "Sets a value.")
+		app.Bool("verbose", 'v', false, "Shows more information about what is being done.")
+
+		app.Command("add", "Sums two numbers.", nil)
+		app.Command("subtract", "Subtracts two numbers, showing the result in the standard output.", nil)
+
+		if err := compareHelpOutput(app, []string{"-h"}, test.file); err != nil {
+			t.Errorf("Case %d, %v", i, err)
+		}
+	}
+}
+
+func TestTemplateHelp(t *testing.T) {
+	tests := []struct {
+		overrides map[string]string
+		file      string
+	}{
+		{file: "testdata/commandargs.golden"},
+		{overrides: map[string]string{"commands": "{{range .Commands}}\nAvailable commands:\n{{range .Commands}}  * {{.Name}}: {{.Brief}}\n{{end}}{{end}}"}, file: "testdata/template-commands.golden"},
+		{overrides: map[string]string{"help": `{{template "brief" .}}{{template "options" .}}{{template "footer" .}}`, "footer": "\nSee the manual of {{.Name}} for details.\n"}, file: "testdata/template-main.golden"},
+	}
+
+	for i, test := range tests {
+		app := libcmd.NewApp("app", "some brief description")
+		app.Long = "this is a very long description"
+
+		app.String("astring", 's', "somevalue", "Sets a string value.")
+		app.Int("aint", 'i', 100, "Sets a int value.")
+
+		app.Command("add", "Sums two numbers.", nil)
+		app.Command("sub", "Subtract two numbers.", nil)
+
+		onHelp, err := libcmd.TemplateHelp(test.overrides)
+		if err != nil {
+			t.Errorf("Case %d, error parsing template: %v", i, err)
+			continue
+		}
+
+		app.Options.OnHelp = onHelp
+
+		if err := compareHelpOutput(app, []string{"-h"}, test.file); err != nil {
+			t.Errorf("Case %d, %v", i, err)
+		}
+	}
+}
+
+func TestTemplateHelpError(t *testing.T) {
+	if _, err := libcmd.TemplateHelp(map[string]string{"usage": "{{.Usage"}); err == nil {
+		t.Errorf("An invalid template should return an error")
+	}
+}
+
+func TestHelpExamples(t *testing.T) {
+	app := libcmd.NewApp("app", "some brief description")
+	app.Options.HelpWidth = 50
+
+	app.Bool("recursive", 'r', false, "Copies the directories recursively.")
+	app.AddOperand("src", "")
+	app.AddOperand("dst", "")
+
+	app.Examples = []libcmd.Example{
+		{Command: "app src dst", Description: "Copies the file 'src' to 'dst'."},
+		{Command: "app -r dir1 dir2", Description: "Copies the contents of directory 'dir1' to the directory 'dir2'."},
+		{Command: "app -h"},
+	}
+
+	if err := compareHelpOutput(app, []string{"-h"}, "testdata/examples.golden"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	tests := []struct {
+		cmd       []string
+		file      string
+		expectErr string
+	}{
+		{cmd: []string{"help"}, file: "testdata/help-command-app.golden"},
+		{cmd: []string{"help", "deploy", "rollback"}, file: "testdata/help-command-deep.golden"},
+		{cmd: []string{"help", "dp", "rollback"}, file: "testdata/help-command-deep.golden"},
+		{cmd: []string{"deploy", "rollback", "-h"}, file: "testdata/help-command-deep.golden"},
+		{cmd: []string{"help", "deploy", "rollbak"}, expectErr: "unknown command 'rollbak' for 'app deploy' (did you mean 'rollback'?)"},
+		{cmd: []string{"help", "delpoy"}, expectErr: "unknown command 'delpoy' for 'app' (did you mean 'deploy'?)"},
+		{cmd: []string{"help", "de"}, expectErr: "unknown command 'de' for 'app' (did you mean 'dp' or 'debug'?)"},
+		{cmd: []string{"help", "xyz"}, expectErr: "unknown command 'xyz' for 'app'"},
+	}
+
+	for i, test := range tests {
+		var b strings.Builder
+		app := libcmd.NewApp("app", "some brief description")
+		app.Options.HelpCommand = true
+		app.Options.HelpOutput = &b
+
+		app.Command("deploy", "Deploys the app.", func(cmd *libcmd.Cmd) {
+			cmd.Command("rollback", "Rolls back a deploy.", func(cmd *libcmd.Cmd) {
+				cmd.Int("revision", 'r', 0, "The revision to roll back to.")
+				cmd.Run(func(*libcmd.Cmd) error {
+					t.Errorf("Case %d, the command should not run", i)
+					return nil
+				})
+			})
+		}).Aliases = []string{"dp"}
+		app.Command("debug", "Debugs the app.", nil)
+
+		err := app.ParseArgs(test.cmd)
+		if test.expectErr != "" {
+			if !libcmd.IsParserErr(err) || err.Error() != test.expectErr {
+				t.Errorf("Case %d, expected error '%s', but got '%v'", i, test.expectErr, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Case %d, error running parser: %v", i, err)
+			continue
+		}
+
+		expected, err := ioutil.ReadFile(test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b.String() != string(expected) {
+			t.Errorf("Case %d, wrong output. Expected:\n>>>\n%s\n<<<\nActual:\n>>>\n%s\n<<<", i, expected, b.String())
+		}
+	}
+}
+
+func newTreeApp() *libcmd.App {
+	app := libcmd.NewApp("app", "some brief description")
+	app.Options.HelpAllFlag = true
+	app.Options.HelpTreeFlag = true
+
+	app.Command("deploy", "Deploys the app.", func(cmd *libcmd.Cmd) {
+		cmd.Bool("force", 'f', false, "Deploys even with errors.")
+		cmd.Command("rollback", "Rolls back a deploy.", func(cmd *libcmd.Cmd) {
+			cmd.Int("revision", 'r', 0, "The revision to roll back to.")
+		})
+		cmd.Command("status", "Shows the deploy status.", nil)
+	}).Aliases = []string{"dp"}
+	app.Command("debug", "Debugs the app.", nil).Hidden = true
+	app.Command("version", "Shows the version.", nil)
+
+	return app
+}
+
+func TestHelpAll(t *testing.T) {
+	if err := compareHelpOutput(newTreeApp(), []string{"--help-all"}, "testdata/help-all.golden"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHelpTree(t *testing.T) {
+	if err := compareHelpOutput(newTreeApp(), []string{"--help-tree"}, "testdata/help-tree.golden"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHelpTopics(t *testing.T) {
+	tests := []struct {
+		cmd       []string
+		file      string
+		expectErr string
+	}{
+		{cmd: []string{"-h"}, file: "testdata/topics-app.golden"},
+		{cmd: []string{"help", "filters"}, file: "testdata/topics-filters.golden"},
+		{cmd: []string{"help", "exit-codes"}, file: "testdata/topics-exit-codes.golden"},
+		{cmd: []string{"help", "filter"}, expectErr: "unknown command 'filter' for 'app' (did you mean 'filters'?)"},
+	}
+
+	for i, test := range tests {
+		app := libcmd.NewApp("app", "some brief description")
+		app.Options.HelpCommand = true
+		app.Options.HelpWidth = 60
+
+		app.HelpTopic("filters", "The filter syntax.", "Filters are written as 'field=value' pairs, separated by commas. Values can use '*' as a wildcard.")
+		app.HelpTopic("exit-codes", "", "0: success\n1: error\n2: invalid arguments")
+		app.HelpTopic("filters", "Filter syntax.", "Filters are written as 'field=value' pairs, separated by commas. Values can use '*' as a wildcard.")
+		app.Command("list", "Lists the items.", nil)
+
+		if test.expectErr != "" {
+			if err := app.ParseArgs(test.cmd); err == nil || err.Error() != test.expectErr {
+				t.Errorf("Case %d, expected error '%s', but got '%v'", i, test.expectErr, err)
+			}
+			continue
+		}
+
+		if err := compareHelpOutput(app, test.cmd, test.file); err != nil {
+			t.Errorf("Case %d, %v", i, err)
+		}
+	}
+}
+
+func TestHelpFlagOnLeaf(t *testing.T) {
+	tests := []struct {
+		args     []string
+		matched  bool
+		ran      bool
+		showHelp bool
+	}{
+		{args: []string{"-h", "add"}, matched: true, ran: true},
+		{args: []string{"add", "-h"}, matched: true, showHelp: true},
+		{args: []string{"--help", "add", "-h"}, matched: true, showHelp: true},
+	}
+
+	for i, test := range tests {
+		var matched, ran bool
+		var b strings.Builder
+
+		app := libcmd.NewApp("app", "some brief description")
+		app.Options.HelpOutput = &b
+		app.Match(func(*libcmd.Cmd) {
+			matched = true
+		})
+
+		add := app.CommandRun("add", "Sums two numbers.", func(*libcmd.Cmd) error {
+			ran = true
+			return nil
+		})
+		add.AddOperand("a", "")
+
+		if err := app.ParseArgs(test.args); err != nil {
+			t.Errorf("Case %d, error parsing args: %v", i, err)
+			continue
+		}
+
+		if matched != test.matched {
+			t.Errorf("Case %d, wrong match: expected %v, got %v", i, test.matched, matched)
+		}
+
+		if ran != test.ran {
+			t.Errorf("Case %d, wrong run: expected %v, got %v", i, test.ran, ran)
+		}
+
+		var expected strings.Builder
+		if test.showHelp {
+			add.PrintHelp(&expected)
+		}
+
+		if b.String() != expected.String() {
+			t.Errorf("Case %d, wrong output. Expected:\n>>>\n%s\n<<<\nActual:\n>>>\n%s\n<<<", i, expected.String(), b.String())
+		}
+	}
+}
+
+func TestPrintUsageOnError(t *testing.T) {
+	tests := []struct {
+		cmd         []string
+		helpCommand bool
+		expected    string
+	}{
+		{cmd: []string{"--unknown"}, expected: "error: unknown argument: --unknown\n\nUSAGE: app [OPTIONS...] COMMAND\n\nRun 'app --help' for more information.\n"},
+		{cmd: []string{"sub", "-n"}, expected: "error: no value for argument: -n\n\nUSAGE: app sub [OPTIONS...] [name | COMMAND]\n\nRun 'app sub --help' for more information.\n"},
+		{cmd: []string{"sub"}, expected: "error: wrong number of operands, exactly 1 required (got 0)\n\nUSAGE: app sub [OPTIONS...] [name | COMMAND]\n\nRun 'app sub --help' for more information.\n"},
+		{cmd: []string{"sub", "deep", "x"}, helpCommand: true, expected: "error: wrong number of operands, exactly 0 required (got 1)\n\nUSAGE: app sub deep\n\nRun 'app help sub deep' for more information.\n"},
+		{cmd: []string{"sub", "deep", "x"}, expected: "error: wrong number of operands, exactly 0 required (got 1)\n\nUSAGE: app sub deep\n"},
+	}
+
+	for i, test := range tests {
+		var b strings.Builder
+		app := libcmd.NewApp("app", "")
+		app.Options.PrintUsageOnError = true
+		app.Options.StrictOperands = true
+		app.Options.HelpCommand = test.helpCommand
+		app.Options.ErrorOutput = &b
+
+		app.Command("sub", "", func(cmd *libcmd.Cmd) {
+			cmd.String("name", 'n', "", "The name.")
+			cmd.AddOperand("name", "")
+			cmd.Run(func(*libcmd.Cmd) error { return nil })
+
+			cmd.CommandRun("deep", "", func(*libcmd.Cmd) error { return nil })
+		})
+
+		if err := app.ParseArgs(test.cmd); !libcmd.IsParserErr(err) {
+			t.Errorf("Case %d, expected a parser error, got %v", i, err)
+		}
+
+		compareValue(t, i, test.expected, b.String())
+	}
+}
+
+func TestPrintUsageOnErrorDisabled(t *testing.T) {
+	var b strings.Builder
+	app := libcmd.NewApp("app", "")
+	app.Options.ErrorOutput = &b
+
+	if err := app.ParseArgs([]string{"--unknown"}); err == nil {
+		t.Errorf("Expected a parser error")
+	}
+
+	compareValue(t, 0, "", b.String())
+}
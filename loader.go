@@ -0,0 +1,286 @@
+package libcmd
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// the Go types of the arguments, by the name used on the specs
+var specTypes = map[string]reflect.Type{
+	"bool":    reflect.TypeOf(false),
+	"string":  reflect.TypeOf(""),
+	"int":     reflect.TypeOf(int(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+}
+
+// NewAppFromSpec builds an app from it's description (see Spec), defining
+// every command, argument and operand of the spec. This allows thin wrappers
+// to have their command-line interface driven by data files.
+//
+// The runs parameter attaches a Run callback to the commands, by their
+// full path (like 'app sub'). Since the arguments are not bound to
+// variables, use the Get methods of Cmd (like GetString) to read their values.
+//
+// The spec is validated before the app is built; the returned error
+// points to the offending element, like 'app.commands[1].options[0]'.
+// Arguments of type 'custom' are not supported.
+//
+// The automatic commands and flags found on specs exported by App.WriteSpec
+// (the ones marked as 'auto', like the 'help' command) are not defined from
+// the spec; instead, the matching Options (like HelpCommand) are enabled, so
+// the app creates them. A '--help' bool flag is always skipped, since the app
+// creates it automatically.
+func NewAppFromSpec(spec *Spec, runs map[string]RunCallback) (*App, error) {
+	if spec.Schema > SpecSchema {
+		return nil, specErr{path: "schema", msg: fmt.Sprintf("unsupported schema %d (the newest supported is %d)", spec.Schema, SpecSchema)}
+	}
+
+	if err := validateSpecCommand(&spec.App, "app"); err != nil {
+		return nil, err
+	}
+
+	app := NewApp(spec.App.Name, spec.App.Brief)
+	app.Options.Version = spec.Version
+	buildSpecCommand(app.Cmd, &spec.App)
+
+	if err := app.attachRuns(runs); err != nil {
+		return nil, err
+	}
+
+	return app, nil
+}
+
+// registers the Run callbacks, by the full path of the commands
+func (cmd *Cmd) attachRuns(runs map[string]RunCallback) error {
+	for path, run := range runs {
+		c := cmd.findPath(path)
+		if c == nil {
+			return fmt.Errorf("run callback registered for unknown command '%s'", path)
+		}
+
+		c.Run(run)
+	}
+
+	return nil
+}
+
+// finds a subcommand by it's full path, like 'app sub'
+func (cmd *Cmd) findPath(path string) *Cmd {
+	names := strings.Fields(path)
+	if len(names) == 0 || names[0] != cmd.Name {
+		return nil
+	}
+
+	for _, name := range names[1:] {
+		if cmd = cmd.commands[name]; cmd == nil {
+			return nil
+		}
+	}
+
+	return cmd
+}
+
+// LoadApp reads a JSON spec (see ReadSpec) and builds an app
+// from it (see NewAppFromSpec).
+func LoadApp(reader io.Reader, runs map[string]RunCallback) (*App, error) {
+	spec, err := ReadSpec(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAppFromSpec(spec, runs)
+}
+
+func validateSpecCommand(spec *SpecCommand, path string) error {
+	if spec.Name == "" {
+		return specErr{path: path, msg: "empty command name"}
+	}
+
+	names := make(map[string]bool)
+	for i, opt := range spec.Options {
+		optPath := fmt.Sprintf("%s.options[%d]", path, i)
+
+		if err := validateSpecOption(&opt, optPath); err != nil {
+			return err
+		}
+
+		for _, name := range opt.names() {
+			if names[name] {
+				return specErr{path: optPath, msg: fmt.Sprintf("duplicated argument name '%s'", name)}
+			}
+
+			names[name] = true
+		}
+	}
+
+	for i, op := range spec.Operands {
+		opPath := fmt.Sprintf("%s.operands[%d]", path, i)
+
+		if op.Name == "" {
+			return specErr{path: opPath, msg: "empty operand name"}
+		}
+
+		if op.Modifier != "" && op.Modifier != "?" && op.Modifier != "*" {
+			return specErr{path: opPath, msg: fmt.Sprintf("invalid modifier '%s'", op.Modifier)}
+		}
+	}
+
+	names = make(map[string]bool)
+	for i := range spec.Commands {
+		c := &spec.Commands[i]
+		cmdPath := fmt.Sprintf("%s.commands[%d]", path, i)
+
+		for _, name := range append([]string{c.Name}, c.Aliases...) {
+			if names[name] {
+				return specErr{path: cmdPath, msg: fmt.Sprintf("duplicated command name '%s'", name)}
+			}
+
+			names[name] = true
+		}
+
+		if err := validateSpecCommand(c, cmdPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateSpecOption(spec *SpecOption, path string) error {
+	if spec.Long == "" && spec.Short == "" {
+		return specErr{path: path, msg: "argument without name"}
+	}
+
+	if spec.Short != "" && utf8.RuneCountInString(spec.Short) != 1 {
+		return specErr{path: path, msg: fmt.Sprintf("short name '%s' must have a single character", spec.Short)}
+	}
+
+	if spec.Type == "choice" {
+		if len(spec.Choices) == 0 {
+			return specErr{path: path, msg: "choice argument without choices"}
+		}
+
+		return nil
+	}
+
+	typ, ok := specTypes[spec.Type]
+	if !ok {
+		return specErr{path: path, msg: fmt.Sprintf("unsupported type '%s'", spec.Type)}
+	}
+
+	if len(spec.Choices) > 0 {
+		return specErr{path: path, msg: fmt.Sprintf("choices are not allowed on arguments of type '%s'", spec.Type)}
+	}
+
+	if spec.Default != "" {
+		if _, err := valueAsKind(spec.Default, typ.Kind(), typ); err != nil {
+			return specErr{path: path, msg: fmt.Sprintf("invalid default value: %v", err)}
+		}
+	}
+
+	return nil
+}
+
+// defines the arguments, operands and subcommands of an (already validated) spec
+func buildSpecCommand(cmd *Cmd, spec *SpecCommand) {
+	cmd.Long = spec.Long
+	cmd.Usage = spec.Usage
+	cmd.Aliases = spec.Aliases
+	cmd.Category = spec.Category
+	cmd.Hidden = spec.Hidden
+	cmd.Deprecated = spec.Deprecated
+
+	for _, opt := range spec.Options {
+		if opt.Auto {
+			cmd.enableAuto("--" + opt.Long)
+			continue
+		}
+
+		// the automatic help flag of specs without the 'auto' field
+		if opt.Long == "help" && opt.Type == "bool" {
+			continue
+		}
+
+		var short rune
+		if opt.Short != "" {
+			short, _ = utf8.DecodeRuneInString(opt.Short)
+		}
+
+		help := []string{opt.Help}
+		if opt.ValueName != "" {
+			help = append(help, opt.ValueName)
+		}
+
+		if opt.Type == "choice" {
+			cmd.ChoiceP(new(string), opt.Choices, opt.Long, short, opt.Default, help...)
+		} else {
+			typ := specTypes[opt.Type]
+			target := reflect.New(typ)
+			defaultValue := reflect.Zero(typ)
+
+			if opt.Default != "" {
+				defaultValue, _ = valueAsKind(opt.Default, typ.Kind(), typ)
+			}
+
+			cmd.addOpt(&optEntry{long: opt.Long, short: short, help: help, val: varFromInterface(target.Interface(), defaultValue.Interface())})
+		}
+
+		entry := cmd.optentries[len(cmd.optentries)-1]
+		entry.Aliases = opt.Aliases
+		entry.Group = opt.Group
+		entry.Hidden = opt.Hidden
+		entry.Deprecated = opt.Deprecated
+	}
+
+	for _, op := range spec.Operands {
+		cmd.AddOperand(op.Name, op.Modifier)
+	}
+
+	for i := range spec.Commands {
+		if spec.Commands[i].Auto {
+			cmd.enableAuto(spec.Commands[i].Name)
+			continue
+		}
+
+		c := cmd.Command(spec.Commands[i].Name, spec.Commands[i].Brief, nil)
+		buildSpecCommand(c, &spec.Commands[i])
+	}
+}
+
+// enables the option that creates an automatic command or flag of the
+// main app, so it is created by the app itself instead of the spec
+func (cmd *Cmd) enableAuto(name string) {
+	if cmd.parentCmd != nil {
+		return
+	}
+
+	switch name {
+	case "help":
+		cmd.Options.HelpCommand = true
+	case "version":
+		cmd.Options.VersionCommand = true
+	case "completion":
+		cmd.Options.CompletionCommand = true
+	case "--spec":
+		cmd.Options.SpecFlag = true
+	case "--version":
+		cmd.Options.VersionFlag = true
+	case "--help-all":
+		cmd.Options.HelpAllFlag = true
+	case "--help-tree":
+		cmd.Options.HelpTreeFlag = true
+	}
+}
@@ -0,0 +1,180 @@
+package libcmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+const loaderSpec = `{
+  "schema": 1,
+  "version": "1.0",
+  "app": {
+    "name": "tool",
+    "brief": "A tool.",
+    "options": [
+      {"long": "verbose", "short": "v", "type": "bool"},
+      {"long": "level", "type": "choice", "choices": ["low", "high"], "default": "low"},
+      {"long": "help", "short": "h", "type": "bool", "help": "Show this help message."}
+    ],
+    "commands": [
+      {
+        "name": "copy",
+        "aliases": ["cp"],
+        "options": [
+          {"long": "jobs", "short": "j", "type": "int", "default": "2", "aliases": ["parallel"]}
+        ],
+        "operands": [{"name": "src"}, {"name": "dst", "modifier": "?"}]
+      }
+    ]
+  }
+}`
+
+func TestLoadApp(t *testing.T) {
+	tests := []struct {
+		cmd     []string
+		verbose bool
+		level   string
+		jobs    int
+		args    []string
+	}{
+		{cmd: []string{"cp", "a"}, level: "low", jobs: 2, args: []string{"a"}},
+		{cmd: []string{"-v", "--level", "high", "copy", "--parallel", "8", "a", "b"}, verbose: true, level: "high", jobs: 8, args: []string{"a", "b"}},
+	}
+
+	for i, test := range tests {
+		var ran bool
+
+		app, err := libcmd.LoadApp(strings.NewReader(loaderSpec), map[string]libcmd.RunCallback{
+			"tool copy": func(cmd *libcmd.Cmd) error {
+				ran = true
+				compareValue(t, i, test.jobs, *cmd.GetInt("jobs"))
+				compareArgs(t, i, test.args, cmd.Args())
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := app.ParseArgs(test.cmd); err != nil {
+			t.Errorf("Case %d, error running parser: %v", i, err)
+			continue
+		}
+
+		compareValue(t, i, true, ran)
+		compareValue(t, i, test.verbose, *app.GetBool("verbose"))
+		compareValue(t, i, test.level, *app.GetChoice("level"))
+	}
+}
+
+func TestLoadAppHelp(t *testing.T) {
+	app, err := libcmd.LoadApp(strings.NewReader(loaderSpec), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	app.Options.HelpOutput = &b
+
+	if err := app.ParseArgs([]string{"-h"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(b.String(), "tool - A tool.\n") {
+		t.Errorf("The help flag should print the help text, got:\n%s", b.String())
+	}
+}
+
+func TestNewAppFromSpecRoundTrip(t *testing.T) {
+	original, _ := manApp()
+	spec := original.Spec()
+
+	app, err := libcmd.NewAppFromSpec(spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changes := libcmd.CompareSpecs(spec, app.Spec()); len(changes) != 0 {
+		t.Errorf("The loaded app should have no changes, got: %v", changes)
+	}
+}
+
+func TestLoadAppAutomatic(t *testing.T) {
+	original, _ := manApp()
+	original.Options.VersionFlag = true
+	original.Options.SpecFlag = true
+
+	var spec strings.Builder
+	if err := original.WriteSpec(&spec); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cmd      []string
+		expected string
+	}{
+		{cmd: []string{"help", "copy"}, expected: "app copy - Copies files.\n"},
+		{cmd: []string{"help"}, expected: "app - manages some-thing\n"},
+		{cmd: []string{"--version"}, expected: "app version 1.2.0\n"},
+		{cmd: []string{"--spec"}, expected: "{\n  \"schema\": 1,\n"},
+	}
+
+	for i, test := range tests {
+		app, err := libcmd.LoadApp(strings.NewReader(spec.String()), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var b strings.Builder
+		app.Options.HelpOutput = &b
+
+		if err := app.ParseArgs(test.cmd); err != nil {
+			t.Errorf("Case %d, error running parser: %v", i, err)
+			continue
+		}
+
+		if !strings.HasPrefix(b.String(), test.expected) {
+			t.Errorf("Case %d, expected the output to start with '%s', got:\n%s", i, test.expected, b.String())
+		}
+	}
+}
+
+func TestNewAppFromSpecErrors(t *testing.T) {
+	tests := []struct {
+		spec     string
+		runs     []string
+		expected string
+	}{
+		{spec: `{"schema": 2, "app": {"name": "a"}}`, expected: "unsupported spec schema 2 (the newest supported is 1)"},
+		{spec: `{"app": {}}`, expected: "invalid spec at app: empty command name"},
+		{spec: `{"app": {"name": "a", "options": [{"type": "int"}]}}`, expected: "invalid spec at app.options[0]: argument without name"},
+		{spec: `{"app": {"name": "a", "options": [{"long": "x", "type": "complex"}]}}`, expected: "invalid spec at app.options[0]: unsupported type 'complex'"},
+		{spec: `{"app": {"name": "a", "options": [{"long": "x", "type": "custom"}]}}`, expected: "invalid spec at app.options[0]: unsupported type 'custom'"},
+		{spec: `{"app": {"name": "a", "options": [{"long": "x", "short": "xy", "type": "int"}]}}`, expected: "invalid spec at app.options[0]: short name 'xy' must have a single character"},
+		{spec: `{"app": {"name": "a", "options": [{"long": "x", "type": "choice"}]}}`, expected: "invalid spec at app.options[0]: choice argument without choices"},
+		{spec: `{"app": {"name": "a", "options": [{"long": "x", "type": "int", "choices": ["1"]}]}}`, expected: "invalid spec at app.options[0]: choices are not allowed on arguments of type 'int'"},
+		{spec: `{"app": {"name": "a", "options": [{"long": "x", "type": "int", "default": "abc"}]}}`, expected: "invalid spec at app.options[0]: invalid default value: 'abc' is not a valid int value"},
+		{spec: `{"app": {"name": "a", "options": [{"long": "xx", "type": "int"}, {"long": "y", "aliases": ["xx"], "type": "int"}]}}`, expected: "invalid spec at app.options[1]: duplicated argument name '--xx'"},
+		{spec: `{"app": {"name": "a", "operands": [{"name": "x", "modifier": "+"}]}}`, expected: "invalid spec at app.operands[0]: invalid modifier '+'"},
+		{spec: `{"app": {"name": "a", "commands": [{"name": "b"}, {"name": "c", "aliases": ["b"]}]}}`, expected: "invalid spec at app.commands[1]: duplicated command name 'b'"},
+		{spec: `{"app": {"name": "a", "commands": [{"name": "b", "commands": [{"name": ""}]}]}}`, expected: "invalid spec at app.commands[0].commands[0]: empty command name"},
+		{spec: `{"app": {"name": "a", "commands": [{"name": "b"}]}}`, runs: []string{"a c"}, expected: "run callback registered for unknown command 'a c'"},
+	}
+
+	for i, test := range tests {
+		runs := make(map[string]libcmd.RunCallback)
+		for _, path := range test.runs {
+			runs[path] = func(*libcmd.Cmd) error { return nil }
+		}
+
+		_, err := libcmd.LoadApp(strings.NewReader(test.spec), runs)
+		if err == nil {
+			t.Errorf("Case %d, expected an error", i)
+			continue
+		}
+
+		compareValue(t, i, test.expected, err.Error())
+	}
+}
@@ -0,0 +1,262 @@
+package libcmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManPage holds the fields of the header (the '.TH' line) of the
+// generated man pages. Any empty field uses a sensible default.
+type ManPage struct {
+	// The manual section. The default is '1'
+	Section string
+
+	// The date of the last change. The default is the current month,
+	// like 'January 2006'
+	Date string
+
+	// The source of the command, usually the name and version of the
+	// package. The default is the app name, followed by Options.Version
+	Source string
+
+	// The title of the manual, like 'User Commands'
+	Manual string
+}
+
+// PrintManPage prints the man page of the command, in roff format, to the
+// specified writer. The page is built from the same information used on the
+// help text: name, brief, long description, usage line, operands, options,
+// subcommands and examples. Hidden options and commands are not shown.
+func (cmd *Cmd) PrintManPage(page ManPage, writer io.Writer) {
+	cmd.configure()
+	cmd.setupHelp()
+	page = cmd.manDefaults(page)
+
+	fmt.Fprintf(writer, ".TH %s %s %s %s %s\n", roffQuote(strings.ToUpper(pageName(cmd))), roffQuote(page.Section),
+		roffQuote(page.Date), roffQuote(page.Source), roffQuote(page.Manual))
+
+	fmt.Fprintf(writer, ".SH NAME\n%s", roffEscape(pageName(cmd)))
+	if brief := commandBrief(cmd); brief != "" {
+		fmt.Fprintf(writer, " \\- %s", roffEscape(brief))
+	}
+	fmt.Fprintln(writer)
+
+	if usage := helpUsage(cmd); usage != "" {
+		fmt.Fprintf(writer, ".SH SYNOPSIS\n")
+
+		if rest := strings.TrimPrefix(usage, cmd.path()); rest != usage {
+			fmt.Fprintf(writer, "\\fB%s\\fR%s\n", roffEscape(cmd.path()), roffEscape(rest))
+		} else {
+			fmt.Fprintln(writer, roffEscape(usage))
+		}
+	}
+
+	if cmd.Long != "" {
+		fmt.Fprintf(writer, ".SH DESCRIPTION\n")
+		roffParagraphs(cmd.Long, writer)
+	}
+
+	if len(cmd.operands) > 0 {
+		fmt.Fprintf(writer, ".SH OPERANDS\n")
+
+		for _, op := range cmd.operands {
+			var text string
+			switch op.modifier {
+			case "?":
+				text = "Optional."
+			case "*":
+				text = "Optional, can be repeated."
+			default:
+				text = "Required."
+			}
+
+			fmt.Fprintf(writer, ".TP\n\\fI%s\\fR\n%s\n", roffEscape(op.name), text)
+		}
+	}
+
+	if groups := optionGroups(cmd); len(groups) > 0 {
+		fmt.Fprintf(writer, ".SH OPTIONS\n")
+
+		for _, group := range groups {
+			if group.name != "" {
+				fmt.Fprintf(writer, ".SS %s\n", roffEscape(group.name))
+			}
+
+			for _, entry := range group.entries {
+				fmt.Fprintf(writer, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(entry.helpHeader()), roffEscape(manExplain(entry)))
+			}
+		}
+	}
+
+	if groups := commandGroups(cmd); len(groups) > 0 {
+		fmt.Fprintf(writer, ".SH COMMANDS\n")
+
+		for _, group := range groups {
+			if group.category != "" {
+				fmt.Fprintf(writer, ".SS %s\n", roffEscape(group.category))
+			}
+
+			for _, c := range group.commands {
+				fmt.Fprintf(writer, ".TP\n\\fB%s\\fR\n", roffEscape(commandHeader(c)))
+				if brief := commandBrief(c); brief != "" {
+					fmt.Fprintf(writer, "%s\n", roffEscape(brief))
+				}
+
+				fmt.Fprintf(writer, "See \\fB%s\\fR(%s).\n", roffEscape(pageName(c)), roffEscape(page.Section))
+			}
+		}
+	}
+
+	if len(cmd.Examples) > 0 {
+		fmt.Fprintf(writer, ".SH EXAMPLES\n")
+
+		for _, example := range cmd.Examples {
+			if example.Description != "" {
+				roffParagraphs(example.Description, writer)
+			}
+
+			fmt.Fprintf(writer, ".PP\n.RS 4\n.nf\n%s\n.fi\n.RE\n", roffEscape(example.Command))
+		}
+	}
+
+	if cmd.parentCmd != nil {
+		fmt.Fprintf(writer, ".SH SEE ALSO\n\\fB%s\\fR(%s)\n", roffEscape(pageName(cmd.parentCmd)), roffEscape(page.Section))
+	}
+}
+
+// WriteManPages writes the man page of the app and of every visible
+// subcommand, recursively, to the specified directory. Each command path has
+// it's own page, with the names separated by '-' and the section as extension,
+// like 'app-sub.1'.
+//
+// The subcommands are configured along the way (see Command).
+func (app *App) WriteManPages(page ManPage, dir string) error {
+	return app.writeManPages(app.manDefaults(page), dir)
+}
+
+func (cmd *Cmd) writeManPages(page ManPage, dir string) error {
+	file, err := os.Create(filepath.Join(dir, pageName(cmd)+"."+page.Section))
+	if err != nil {
+		return err
+	}
+
+	writer := &errWriter{writer: file}
+	cmd.PrintManPage(page, writer)
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if writer.err != nil {
+		return writer.err
+	}
+
+	for _, c := range visibleCommands(cmd) {
+		if err := c.writeManPages(page, dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fills the empty fields of the page header
+func (cmd *Cmd) manDefaults(page ManPage) ManPage {
+	if page.Section == "" {
+		page.Section = "1"
+	}
+
+	if page.Date == "" {
+		page.Date = time.Now().Format("January 2006")
+	}
+
+	if page.Source == "" {
+		root := cmd
+		for root.parentCmd != nil {
+			root = root.parentCmd
+		}
+
+		page.Source = strings.TrimSpace(root.Name + " " + root.EffectiveOptions().Version)
+	}
+
+	return page
+}
+
+// a writer that keeps the first error, so a page can be
+// printed with many calls and checked only once
+type errWriter struct {
+	writer io.Writer
+	err    error
+}
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, err := w.writer.Write(p)
+	w.err = err
+	return n, err
+}
+
+// the description of an option; unlike the help text, the
+// valid values of a choice are always listed
+func manExplain(entry *optEntry) string {
+	explain := entry.helpExplain()
+
+	choice, ok := entry.val.raw.(*choiceString)
+	if !ok {
+		return explain
+	}
+
+	var help string
+	if len(entry.help) > 0 {
+		help = entry.help[0]
+	}
+
+	// an empty help text is replaced by the list, and
+	// a template already has the values expanded
+	if help != "" && !strings.Contains(help, "%s") {
+		explain += " Valid values: " + strings.Trim(strings.Join(choice.choices, ","), ",") + "."
+	}
+
+	return explain
+}
+
+// the name of the documentation page of the command, like 'app-sub'
+func pageName(cmd *Cmd) string {
+	return strings.Replace(cmd.path(), " ", "-", -1)
+}
+
+// escapes the characters with special meaning in roff
+func roffEscape(text string) string {
+	text = strings.Replace(text, `\`, `\e`, -1)
+	text = strings.Replace(text, "-", `\-`, -1)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// escapes a text and puts it between double quotes, to be used
+// as an argument of a roff request
+func roffQuote(text string) string {
+	return `"` + strings.Replace(roffEscape(text), `"`, `\(dq`, -1) + `"`
+}
+
+// prints a text, starting a new paragraph at each blank line
+func roffParagraphs(text string, writer io.Writer) {
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			fmt.Fprintf(writer, ".PP\n%s\n", roffEscape(paragraph))
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package libcmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func manApp() (*libcmd.App, *libcmd.Cmd) {
+	app := libcmd.NewApp("app", "manages some-thing")
+	app.Long = "This is the first paragraph.\n\n.This one starts with a dot and has a \\backslash."
+	app.Options.Version = "1.2.0"
+	app.Options.HelpCommand = true
+
+	app.Bool("verbose", 'v', false, "Show more details.")
+	app.Choice([]string{"red", "green"}, "color", 'c', "red", "The color.")
+	app.Int("jobs", 'j', 2, "Number of jobs.")
+	app.Opt("jobs").Group = "Performance"
+	app.String("secret", 0, "", "Not shown.")
+	app.Opt("secret").Hidden = true
+
+	sub := app.Command("copy", "Copies files.", func(cmd *libcmd.Cmd) {
+		cmd.AddOperand("src", "")
+		cmd.AddOperand("dst", "?")
+		cmd.Examples = []libcmd.Example{
+			{Command: "app copy -r a b", Description: "Copies a to b."},
+		}
+
+		cmd.Bool("recursive", 'r', false, "Copy directories.")
+		cmd.Run(func(*libcmd.Cmd) error { return nil })
+	})
+	sub.Aliases = []string{"cp"}
+	sub.Category = "Files"
+
+	app.Command("internal", "", nil).Hidden = true
+
+	return app, sub
+}
+
+func TestManPage(t *testing.T) {
+	tests := []struct {
+		sub  bool
+		file string
+	}{
+		{file: "testdata/man-app.golden"},
+		{sub: true, file: "testdata/man-copy.golden"},
+	}
+
+	for i, test := range tests {
+		bytes, err := ioutil.ReadFile(test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		app, sub := manApp()
+		var b strings.Builder
+		page := libcmd.ManPage{Date: "March 2024", Manual: "User Commands"}
+
+		if test.sub {
+			sub.PrintManPage(page, &b)
+		} else {
+			app.PrintManPage(page, &b)
+		}
+
+		compareValue(t, i, string(bytes), b.String())
+	}
+}
+
+func TestWriteManPages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "libcmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	app, _ := manApp()
+	if err := app.WriteManPages(libcmd.ManPage{Section: "8"}, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	sort.Strings(names)
+
+	compareValue(t, 0, "app-copy.8,app-help.8,app.8", strings.Join(names, ","))
+}
+
+func TestManPageChoices(t *testing.T) {
+	app := libcmd.NewApp("app", "")
+	app.Choice([]string{"a", "b"}, "none", 0, "")
+	app.Choice([]string{"a", "b"}, "empty", 0, "", "")
+	app.Choice([]string{"", "a", "b"}, "text", 0, "", "Picks one.")
+	app.Choice([]string{"a", "b"}, "template", 0, "", "Picks %s.")
+
+	var b strings.Builder
+	app.PrintManPage(libcmd.ManPage{}, &b)
+
+	tests := []struct {
+		name    string
+		explain string
+	}{
+		{name: "none", explain: "Valid values: a,b."},
+		{name: "empty", explain: "Valid values: a,b."},
+		{name: "text", explain: "Picks one. Valid values: a,b."},
+		{name: "template", explain: "Picks a,b."},
+	}
+
+	for i, test := range tests {
+		header := "\\fB\\-\\-" + test.name + "=value\\fR\n"
+		text := b.String()
+		if pos := strings.Index(text, header); pos < 0 {
+			t.Errorf("Case %d, option '%s' not found", i, test.name)
+		} else {
+			text = text[pos+len(header):]
+			compareValue(t, i, test.explain, text[:strings.Index(text, "\n")])
+		}
+	}
+}
+
+func TestWriteManPagesError(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+
+	dir, err := ioutil.TempDir("", "libcmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// every write to the page fails
+	if err := os.Symlink("/dev/full", filepath.Join(dir, "app.1")); err != nil {
+		t.Skip(err)
+	}
+
+	app, _ := manApp()
+	if err := app.WriteManPages(libcmd.ManPage{}, dir); err == nil {
+		t.Errorf("A failed write should return an error")
+	}
+}
@@ -1,7 +1,9 @@
 package libcmd
 
 import (
+	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 // helper struct to determine what kind of argument was
@@ -40,13 +42,54 @@ func parseOptArg(argstr string) *optArg {
 	return &arg
 }
 
+// Opt holds the extra settings of an argument. These settings
+// are optional, and can be changed after the argument is defined,
+// by calling Opt on the command that owns the argument.
+type Opt struct {
+	// When true, the argument still works, but is not shown
+	// on the help text.
+	Hidden bool
+
+	// Alternative names for the argument. Names with a single
+	// character are used as short names (e. g. '-o'), while the others
+	// are used as long names (e. g. '--out'). Only the main names are
+	// shown on the help text. The aliases are registered the first time
+	// the command is parsed; an alias already used by another argument
+	// of the same command panics.
+	Aliases []string
+
+	// When true, using one of the aliases prints a notice
+	// asking the user to use the main name instead.
+	WarnAlias bool
+
+	// When set, marks the argument as deprecated, printing a
+	// warning when it is used.
+	Deprecated *Deprecation
+
+	// The group of the argument. Arguments of the same group
+	// are shown together, under the group name, on the help text.
+	Group string
+
+	// Callback that runs when the argument is set in the command-line.
+	// Return ErrStop to end the processing (see OptCallback).
+	OnSet OptCallback
+
+	// Callback that returns the candidate values of the argument,
+	// used by the dynamic completion (see Options.DynamicCompletion).
+	// When it is nil, the choices of arguments defined with Choice are used
+	Complete CompleteCallback
+}
+
 // inner struct to hold the values of each command line
 // entry. Holds the definition provided by the user.
 type optEntry struct {
-	long  string
-	short rune
-	help  []string
-	val   *variant
+	Opt
+	long    string
+	short   rune
+	help    []string
+	val     *variant
+	aliased bool
+	auto    bool
 }
 
 func (entry *optEntry) helpHeader() string {
@@ -108,6 +151,10 @@ func (entry *optEntry) helpExplain() string {
 		explain += " (default: " + def + ")"
 	}
 
+	if entry.Deprecated != nil {
+		explain += " (deprecated)"
+	}
+
 	return explain
 }
 
@@ -149,6 +196,32 @@ func (entry *optEntry) setValue(arg *optArg) error {
 	return nil
 }
 
+// the main name of the entry, as expected in the command-line
+func (entry *optEntry) mainName(negated bool) string {
+	switch {
+	case entry.long != "" && negated && entry.val.isBool:
+		return "--no-" + entry.long
+	case entry.long != "":
+		return "--" + entry.long
+	default:
+		return "-" + string(entry.short)
+	}
+}
+
+// checks if the name used in the command-line is one of
+// the aliases of the entry
+func (entry *optEntry) isAlias(name string) bool {
+	if entry.short != 0 && name == "-"+string(entry.short) {
+		return false
+	}
+
+	if entry.long != "" && (name == "--"+entry.long || name == "--no-"+entry.long) {
+		return false
+	}
+
+	return true
+}
+
 // add and optentry to the parser
 func (cmd *Cmd) addOpt(entry *optEntry) {
 	if entry.short < 0 {
@@ -174,6 +247,51 @@ func (cmd *Cmd) addOpt(entry *optEntry) {
 	}
 }
 
+// register the aliases of every entry in the parser, once
+func (cmd *Cmd) setupAliases() {
+	for _, entry := range cmd.optentries {
+		if entry.aliased {
+			continue
+		}
+
+		entry.aliased = true
+
+		for _, alias := range entry.Aliases {
+			if utf8.RuneCountInString(alias) == 1 {
+				cmd.registerAlias(cmd.shortopt, "-"+alias, entry)
+				continue
+			}
+
+			cmd.registerAlias(cmd.longopt, "--"+alias, entry)
+
+			if entry.val.isBool {
+				cmd.registerAlias(cmd.longopt, "--no-"+alias, entry)
+			}
+		}
+	}
+}
+
+// adds an alias to the parser, panicking if
+// the name is used by another entry
+func (cmd *Cmd) registerAlias(names map[string]*optEntry, name string, entry *optEntry) {
+	if other, ok := names[name]; ok && other != entry {
+		panic("duplicated argument name: " + name)
+	}
+
+	names[name] = entry
+}
+
+// prints a notice when the user invokes an entry
+// by an alias that should not be used anymore
+func (cmd *Cmd) warnAlias(entry *optEntry, name string) {
+	if !entry.WarnAlias || !entry.isAlias(name) {
+		return
+	}
+
+	d := Deprecation{Replacement: entry.mainName(strings.HasPrefix(name, "--no-"))}
+	fmt.Fprintf(cmd.warningOutput(), "warning: %s\n", d.warning(name))
+}
+
 // find an entry (with '-' or '--')
 func (cmd *Cmd) findOpt(entryName string) *optEntry {
 	if entry, ok := cmd.shortopt[entryName]; ok {
@@ -183,8 +301,18 @@ func (cmd *Cmd) findOpt(entryName string) *optEntry {
 	return cmd.longopt[entryName]
 }
 
+// runs the callback of an entry that was set. On a
+// dry run, nothing is executed
+func (cmd *Cmd) notifySet(entry *optEntry, dryRun bool) error {
+	if entry.OnSet == nil || dryRun {
+		return nil
+	}
+
+	return entry.OnSet(cmd)
+}
+
 // parse all command-line arguments
-func (cmd *Cmd) doParse(args []string) error {
+func (cmd *Cmd) doParse(args []string, dryRun bool) error {
 	for i := 0; i < len(args); i++ {
 
 		// parse the current argument
@@ -198,7 +326,7 @@ func (cmd *Cmd) doParse(args []string) error {
 
 		// if is a bunch of flags in 'compressed' form,
 		// process them all, except the last one
-		if err := cmd.processMultiArgs(arg); err != nil {
+		if err := cmd.processMultiArgs(arg, dryRun); err != nil {
 			return err
 		}
 
@@ -209,6 +337,11 @@ func (cmd *Cmd) doParse(args []string) error {
 			return unknownArgErr{arg: arg.name}
 		}
 
+		cmd.warnAlias(entry, arg.name)
+		if err := cmd.checkDeprecated(entry.Deprecated, arg.name); err != nil {
+			return err
+		}
+
 		// some argument types have automatic values in certain cases
 		// fill them in, if necessary
 		entry.fillAutoValue(arg)
@@ -227,6 +360,10 @@ func (cmd *Cmd) doParse(args []string) error {
 		if err := entry.setValue(arg); err != nil {
 			return err
 		}
+
+		if err := cmd.notifySet(entry, dryRun); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -236,7 +373,7 @@ func (cmd *Cmd) doParse(args []string) error {
 // Every have to be a bool, except the last one. This routine sets
 // the value of every flag, excet the last, and adjusts 'arg' so it points
 // to the last flag only
-func (cmd *Cmd) processMultiArgs(arg *optArg) error {
+func (cmd *Cmd) processMultiArgs(arg *optArg, dryRun bool) error {
 	if !arg.isShort || len(arg.name) <= 2 {
 		return nil
 	}
@@ -248,9 +385,18 @@ func (cmd *Cmd) processMultiArgs(arg *optArg) error {
 			return unknownArgErr{arg: arg.name}
 		}
 
+		cmd.warnAlias(entry, "-"+names[i])
+		if err := cmd.checkDeprecated(entry.Deprecated, "-"+names[i]); err != nil {
+			return err
+		}
+
 		if err := entry.val.setValue("true"); err != nil {
 			return err
 		}
+
+		if err := cmd.notifySet(entry, dryRun); err != nil {
+			return err
+		}
 	}
 
 	arg.name = "-" + names[len(names)-1]
@@ -258,54 +404,93 @@ func (cmd *Cmd) processMultiArgs(arg *optArg) error {
 }
 
 func (cmd *Cmd) doRun(args []string) error {
+	if cmd.parentCmd == nil && len(args) > 0 && args[0] == completeCommand && cmd.EffectiveOptions().DynamicCompletion {
+		cmd.printCandidates(args[1:], cmd.helpOutput())
+		return nil
+	}
+
+	leaf, err := cmd.dispatch(args, false)
+	if err == ErrStop {
+		return nil
+	}
+
+	if err == nil {
+		err = leaf.runLeafCommand()
+	}
+
+	if IsParserErr(err) && leaf.EffectiveOptions().PrintUsageOnError {
+		leaf.printUsageError(err)
+	}
+
+	return err
+}
+
+// parses the arguments and selects the subcommand to run, recursively,
+// returning the 'leaf' command that should be executed. On a dry run, the
+// match callbacks and the error handlers are not executed. When the parsing
+// fails, the returned command is the one that failed
+func (cmd *Cmd) dispatch(args []string, dryRun bool) (*Cmd, error) {
 	cmd.setupHelp()
+	cmd.setupAliases()
 
-	if err := cmd.doParse(args); err != nil {
-		if cmd.errHandler != nil {
+	// forget the results of previous runs
+	cmd.args = make([]string, 0)
+	for _, entry := range cmd.optentries {
+		entry.val.isSet = false
+	}
+
+	if err := cmd.doParse(args, dryRun); err != nil {
+		if err == ErrStop {
+			return nil, err
+		}
+
+		if cmd.errHandler != nil && !dryRun {
 			err = cmd.errHandler(err)
 		}
 
 		if err != nil {
-			return err
+			return cmd, err
 		}
 	}
 
 	for i := range cmd.optentries {
 		if err := cmd.optentries[i].val.useDefault(); err != nil {
-			return err
+			return cmd, err
 		}
 	}
 
-	if cmd.match != nil {
+	if cmd.match != nil && !dryRun {
 		cmd.match(cmd)
 	}
 
 	if len(cmd.args) >= 1 {
 		name := cmd.args[0]
 
-		if subCommand, ok := cmd.commands[name]; ok {
-			subCommand.Options = cmd.Options
-			if subCommand.callback != nil {
-				subCommand.callback(subCommand)
+		if subCommand := cmd.findCommand(name); subCommand != nil {
+			subCommand.configure()
+
+			if err := subCommand.checkDeprecated(subCommand.Deprecated, name); err != nil {
+				return subCommand, err
 			}
 
-			err := subCommand.doRun(cmd.args[1:])
+			leaf, err := subCommand.dispatch(cmd.args[1:], dryRun)
 			cmd.args = subCommand.args
 
-			return err
+			return leaf, err
 		}
 	}
 
 	// leaf command
-	return cmd.runLeafCommand()
+	return cmd, nil
 }
 
 func (cmd *Cmd) runLeafCommand() error {
 	var showHelp bool
+	options := cmd.EffectiveOptions()
 
 	// check for the automatic handling of
 	// the '-h' and '--help' flags
-	if !cmd.Options.SupressPrintHelpWhenSet {
+	if !options.SupressPrintHelpWhenSet {
 		arg := cmd.shortopt["-h"]
 		if arg == nil {
 			arg = cmd.longopt["--help"]
@@ -322,7 +507,7 @@ func (cmd *Cmd) runLeafCommand() error {
 	}
 
 	// check for operands
-	if err := cmd.checkOperands(); err != nil {
+	if err := cmd.checkOperands(options.StrictOperands); err != nil {
 		return err
 	}
 
@@ -331,6 +516,10 @@ func (cmd *Cmd) runLeafCommand() error {
 		return cmd.run(cmd)
 	}
 
+	if cmd.runContext != nil {
+		return cmd.runContext(cmd.Context(), cmd)
+	}
+
 	// if i'm the main app, do not show the help
 	if cmd.parentCmd == nil {
 		return nil
@@ -338,16 +527,16 @@ func (cmd *Cmd) runLeafCommand() error {
 
 	// the last resort is to run the help if we're a "partial"
 	// subcommand
-	if !cmd.Options.SuppressPrintHelpPartialCommand {
+	if !options.SuppressPrintHelpPartialCommand {
 		cmd.Help()
 	}
 
 	return nil
 }
 
-func (cmd *Cmd) checkOperands() error {
+func (cmd *Cmd) checkOperands(strict bool) error {
 	// if we're permissive, there's nothing to do
-	if !cmd.Options.StrictOperands {
+	if !strict {
 		return nil
 	}
 
@@ -1,6 +1,7 @@
 package libcmd_test
 
 import (
+	"fmt"
 	"math"
 	"strconv"
 	"strings"
@@ -533,3 +534,158 @@ func TestOperandOptional(t *testing.T) {
 		compareValue(t, i, test.value, app.Operand("value"))
 	}
 }
+
+func TestOptAlias(t *testing.T) {
+	tests := []struct {
+		cmd     []string
+		output  string
+		verbose bool
+		warn    bool
+		warning string
+	}{
+		{cmd: []string{}},
+		{cmd: []string{"--output", "a"}, output: "a"},
+		{cmd: []string{"--out", "a"}, output: "a"},
+		{cmd: []string{"--out=a", "-V"}, output: "a", verbose: true},
+		{cmd: []string{"-O", "a", "--loud"}, output: "a", verbose: true},
+		{cmd: []string{"-vO", "a"}, output: "a", verbose: true},
+		{cmd: []string{"--output", "a"}, output: "a", warn: true},
+		{cmd: []string{"--out", "a"}, output: "a", warn: true, warning: "warning: '--out' is deprecated, use '--output' instead\n"},
+		{cmd: []string{"-VO", "a"}, output: "a", verbose: true, warn: true, warning: "warning: '-V' is deprecated, use '--verbose' instead\nwarning: '-O' is deprecated, use '--output' instead\n"},
+		{cmd: []string{"-v", "--no-loud"}, warn: true, warning: "warning: '--no-loud' is deprecated, use '--no-verbose' instead\n"},
+	}
+
+	for i, test := range tests {
+		var b strings.Builder
+		app := libcmd.NewApp("", "")
+		app.Options.WarningOutput = &b
+
+		output := app.String("output", 'o', "", "")
+		verbose := app.Bool("verbose", 'v', false, "")
+
+		app.Opt("output").Aliases = []string{"out", "O"}
+		app.Opt("output").WarnAlias = test.warn
+		app.Opt("v").Aliases = []string{"loud", "V"}
+		app.Opt("v").WarnAlias = test.warn
+
+		if err := app.ParseArgs(test.cmd); err != nil {
+			t.Errorf("Case %d, error parsing args: %v", i, err)
+			continue
+		}
+
+		compareValue(t, i, test.output, *output)
+		compareValue(t, i, test.verbose, *verbose)
+		compareValue(t, i, test.warning, b.String())
+	}
+}
+
+func TestOptAliasConflict(t *testing.T) {
+	tests := []struct {
+		aliases  []string
+		expected string
+	}{
+		{aliases: []string{"out"}},
+		{aliases: []string{"v"}, expected: "duplicated argument name: -v"},
+		{aliases: []string{"verbose"}, expected: "duplicated argument name: --verbose"},
+		{aliases: []string{"output"}},
+	}
+
+	for i, test := range tests {
+		func() {
+			defer func() {
+				var msg string
+				if r := recover(); r != nil {
+					msg = r.(string)
+				}
+
+				compareValue(t, i, test.expected, msg)
+			}()
+
+			app := libcmd.NewApp("", "")
+			app.String("output", 'o', "", "")
+			app.Bool("verbose", 'v', false, "")
+			app.Opt("output").Aliases = test.aliases
+
+			// the aliases are registered only once
+			for j := 0; j < 2; j++ {
+				if err := app.ParseArgs([]string{}); err != nil {
+					t.Errorf("Case %d, error parsing args: %v", i, err)
+				}
+			}
+		}()
+	}
+}
+
+func TestOptOnSet(t *testing.T) {
+	tests := []struct {
+		cmd       []string
+		license   bool
+		traced    int
+		run       bool
+		expectErr string
+	}{
+		{cmd: []string{}, run: true},
+		{cmd: []string{"--license"}, license: true},
+		{cmd: []string{"--license", "--unknown"}, license: true},
+		{cmd: []string{"--trace", "--trace"}, traced: 2, run: true},
+		{cmd: []string{"-tl", "sub"}, traced: 1, license: true},
+		{cmd: []string{"--no-license"}, run: true},
+		{cmd: []string{"--level", "3"}, run: true},
+		{cmd: []string{"--level", "99"}, expectErr: "invalid level: 99"},
+		{cmd: []string{"sub", "--license"}, expectErr: "unknown argument: --license"},
+	}
+
+	for i, test := range tests {
+		var license, run bool
+		var traced int
+
+		app := libcmd.NewApp("", "")
+
+		l := app.Bool("license", 'l', false, "")
+		app.Opt("license").OnSet = func(*libcmd.Cmd) error {
+			if !*l {
+				return nil
+			}
+
+			license = true
+			return libcmd.ErrStop
+		}
+
+		app.Bool("trace", 't', false, "")
+		app.Opt("trace").OnSet = func(*libcmd.Cmd) error {
+			traced++
+			return nil
+		}
+
+		level := app.Int("level", 0, 0, "")
+		app.Opt("level").OnSet = func(*libcmd.Cmd) error {
+			if *level > 10 {
+				return fmt.Errorf("invalid level: %d", *level)
+			}
+			return nil
+		}
+
+		app.Run(func(*libcmd.Cmd) error {
+			run = true
+			return nil
+		})
+
+		app.CommandRun("sub", "", func(*libcmd.Cmd) error {
+			run = true
+			return nil
+		})
+
+		err := app.ParseArgs(test.cmd)
+		if test.expectErr == "" && err != nil {
+			t.Errorf("Case %d, error parsing args: %v", i, err)
+			continue
+		} else if test.expectErr != "" && (err == nil || err.Error() != test.expectErr) {
+			t.Errorf("Case %d, expected error '%s', but got '%v'", i, test.expectErr, err)
+			continue
+		}
+
+		compareValue(t, i, test.license, license)
+		compareValue(t, i, test.traced, traced)
+		compareValue(t, i, test.run, run)
+	}
+}
@@ -0,0 +1,212 @@
+package libcmd
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// SpecSchema is the version of the schema of the Spec documents. It
+// changes whenever a field is renamed or has it's meaning changed.
+const SpecSchema = 1
+
+// Spec is a machine-readable description of the command-line interface
+// of an app: every command, argument and operand, including the hidden ones.
+// It is meant to be exported as JSON (see App.WriteSpec), to be compared
+// between releases or used by other tools.
+type Spec struct {
+	// The version of the schema of this document (see SpecSchema)
+	Schema int `json:"schema"`
+
+	// The version of the app (see Options.Version)
+	Version string `json:"version,omitempty"`
+
+	// The main command of the app
+	App SpecCommand `json:"app"`
+}
+
+// SpecCommand describes a command and it's subcommands.
+type SpecCommand struct {
+	Name       string       `json:"name"`
+	Brief      string       `json:"brief,omitempty"`
+	Long       string       `json:"long,omitempty"`
+	Usage      string       `json:"usage,omitempty"`
+	Aliases    []string     `json:"aliases,omitempty"`
+	Category   string       `json:"category,omitempty"`
+	Hidden     bool         `json:"hidden,omitempty"`
+	Deprecated *Deprecation `json:"deprecated,omitempty"`
+
+	// Created automatically by the app, from it's Options (like the
+	// 'help' command)
+	Auto bool `json:"auto,omitempty"`
+
+	// The arguments, in the order they were declared
+	Options []SpecOption `json:"options,omitempty"`
+
+	// The documented operands, in the order they were added
+	Operands []SpecOperand `json:"operands,omitempty"`
+
+	// The subcommands, sorted by name
+	Commands []SpecCommand `json:"commands,omitempty"`
+}
+
+// SpecOption describes an argument.
+type SpecOption struct {
+	// The names, without dashes. Any of them may be empty
+	Long  string `json:"long,omitempty"`
+	Short string `json:"short,omitempty"`
+
+	Aliases []string `json:"aliases,omitempty"`
+
+	// The type of the value: the name of the Go type ('bool', 'string',
+	// 'int', 'uint8', 'float64', etc.), 'choice' for arguments defined
+	// with Choice and 'custom' for the other arguments defined with Custom
+	Type string `json:"type"`
+
+	Default string   `json:"default,omitempty"`
+	Choices []string `json:"choices,omitempty"`
+
+	// The help text and the name of the value, as passed
+	// when the argument was defined
+	Help      string `json:"help,omitempty"`
+	ValueName string `json:"valueName,omitempty"`
+
+	Group      string       `json:"group,omitempty"`
+	Hidden     bool         `json:"hidden,omitempty"`
+	Deprecated *Deprecation `json:"deprecated,omitempty"`
+
+	// Created automatically by the app, from it's Options (like the
+	// '--help' flag)
+	Auto bool `json:"auto,omitempty"`
+}
+
+// SpecOperand describes an operand. The modifier is the same used in
+// AddOperand: empty for required operands, '?' for optional and '*' for
+// repeating ones.
+type SpecOperand struct {
+	Name     string `json:"name"`
+	Modifier string `json:"modifier,omitempty"`
+}
+
+// Spec returns the description of the command-line interface of the app.
+//
+// The subcommands are configured along the way (see Command).
+func (app *App) Spec() *Spec {
+	return app.spec()
+}
+
+// WriteSpec writes the description of the command-line interface of the
+// app (see Spec) to the specified writer, as indented JSON.
+func (app *App) WriteSpec(writer io.Writer) error {
+	return app.writeSpec(writer)
+}
+
+func (cmd *Cmd) spec() *Spec {
+	return &Spec{
+		Schema:  SpecSchema,
+		Version: cmd.EffectiveOptions().Version,
+		App:     specCommand(cmd),
+	}
+}
+
+func (cmd *Cmd) writeSpec(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(cmd.spec())
+}
+
+func specCommand(cmd *Cmd) SpecCommand {
+	cmd.configure()
+	cmd.setupHelp()
+
+	spec := SpecCommand{
+		Name:       cmd.Name,
+		Brief:      cmd.Brief,
+		Long:       cmd.Long,
+		Usage:      cmd.Usage,
+		Aliases:    cmd.Aliases,
+		Category:   cmd.Category,
+		Hidden:     cmd.Hidden,
+		Deprecated: cmd.Deprecated,
+		Auto:       cmd.auto,
+	}
+
+	for _, entry := range cmd.optentries {
+		spec.Options = append(spec.Options, specOption(entry))
+	}
+
+	for _, op := range cmd.operands {
+		spec.Operands = append(spec.Operands, SpecOperand{Name: op.name, Modifier: op.modifier})
+	}
+
+	names := make([]string, 0, len(cmd.commands))
+	for name := range cmd.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		spec.Commands = append(spec.Commands, specCommand(cmd.commands[name]))
+	}
+
+	return spec
+}
+
+func specOption(entry *optEntry) SpecOption {
+	spec := SpecOption{
+		Long:       entry.long,
+		Aliases:    entry.Aliases,
+		Default:    entry.val.defaultAsString(),
+		Group:      entry.Group,
+		Hidden:     entry.Hidden,
+		Deprecated: entry.Deprecated,
+		Auto:       entry.auto,
+	}
+
+	if entry.short != 0 {
+		spec.Short = string(entry.short)
+	}
+
+	if len(entry.help) > 0 {
+		spec.Help = entry.help[0]
+	}
+
+	if len(entry.help) > 1 {
+		spec.ValueName = entry.help[1]
+	}
+
+	switch raw := entry.val.raw.(type) {
+	case *choiceString:
+		spec.Type = "choice"
+		for _, value := range raw.choices {
+			if value != "" {
+				spec.Choices = append(spec.Choices, value)
+			}
+		}
+
+	case CustomArg:
+		spec.Type = "custom"
+
+	default:
+		spec.Type = entry.val.refValue.Kind().String()
+	}
+
+	return spec
+}
+
+// adds the hidden '--spec' flag, that prints the spec as JSON
+func (cmd *Cmd) setupSpecFlag() {
+	target := cmd.Bool("spec", 0, false, "Print the command-line specification as JSON.")
+	cmd.Opt("spec").Hidden = true
+	cmd.Opt("spec").OnSet = func(*Cmd) error {
+		if !*target {
+			return nil
+		}
+
+		if err := cmd.writeSpec(cmd.helpOutput()); err != nil {
+			return err
+		}
+
+		return ErrStop
+	}
+}
@@ -0,0 +1,50 @@
+package libcmd_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func TestSpec(t *testing.T) {
+	app, _ := manApp()
+	app.Options.SpecFlag = true
+	app.Opt("verbose").Aliases = []string{"V", "loud"}
+	app.Opt("verbose").Deprecated = &libcmd.Deprecation{Replacement: "--debug", RemovedIn: "2.0"}
+	app.String("out", 'o', "", "The output file.", "file")
+
+	if err := compareHelpOutput(app, []string{"--spec"}, "testdata/spec.golden"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSpecValue(t *testing.T) {
+	app, _ := manApp()
+	spec := app.Spec()
+
+	compareValue(t, 0, libcmd.SpecSchema, spec.Schema)
+	compareValue(t, 1, "1.2.0", spec.Version)
+	compareValue(t, 2, "app", spec.App.Name)
+	compareValue(t, 3, 3, len(spec.App.Commands))
+	compareValue(t, 4, "copy", spec.App.Commands[0].Name)
+	compareValue(t, 5, "choice", spec.App.Options[1].Type)
+}
+
+// a writer that always fails
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestSpecFlagError(t *testing.T) {
+	app, _ := manApp()
+	app.Options.SpecFlag = true
+	app.Options.HelpOutput = failWriter{}
+
+	err := app.ParseArgs([]string{"--spec"})
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("Expected the write error, got: %v", err)
+	}
+}
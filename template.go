@@ -0,0 +1,316 @@
+package libcmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// HelpData is the data model used to render the help text of a command.
+// It is the value passed to the templates used by TemplateHelp, but it
+// can also be used to write a custom HelpCallback.
+//
+// Every text is already in the form shown on the automatic help text (e. g.
+// with default values and deprecation notices), and every list contains
+// only the visible (non-hidden) items.
+type HelpData struct {
+	// The full path of the command, like 'app sub'
+	Path string
+
+	// The name and brief description of the command
+	Name  string
+	Brief string
+
+	// The long description of the command
+	Long string
+
+	// The usage line of the command. It is empty when the
+	// usage line should not be shown
+	Usage string
+
+	// True if the command is deprecated
+	Deprecated bool
+
+	// The documented operands, in the order they were added
+	Operands []HelpOperand
+
+	// The options, split by group
+	Options []HelpOptionGroup
+
+	// The subcommands, split by category
+	Commands []HelpCommandGroup
+
+	// The usage examples
+	Examples []Example
+
+	// The help topics (only available on the main app)
+	Topics []HelpTopic
+
+	// The width of the first column of the options, commands
+	// and topics lists
+	OptionColumn  int
+	CommandColumn int
+	TopicColumn   int
+
+	// The width of the help text
+	Width int
+}
+
+// HelpOperand describes an operand on the help text.
+type HelpOperand struct {
+	Name     string
+	Modifier string
+}
+
+// HelpOption describes an option on the help text.
+type HelpOption struct {
+	// The short and long names, with their dashes (e. g. '-s' and '--str').
+	// Any of them may be empty
+	Short string
+	Long  string
+
+	// The text shown on the first column (e. g. '-s, --str=string')
+	Header string
+
+	// The text shown on the second column
+	Description string
+
+	// The default value, if any
+	Default string
+
+	// True if the option is deprecated
+	Deprecated bool
+}
+
+// HelpOptionGroup is a set of options of the same group. The options
+// without a group have an empty Name.
+type HelpOptionGroup struct {
+	Name    string
+	Options []HelpOption
+}
+
+// HelpCommand describes a subcommand on the help text.
+type HelpCommand struct {
+	Name    string
+	Aliases []string
+
+	// The text shown on the first column (name and aliases)
+	Header string
+
+	// The brief description of the command
+	Brief string
+
+	// True if the command is deprecated
+	Deprecated bool
+}
+
+// HelpTopic describes a help topic (see App.HelpTopic).
+type HelpTopic struct {
+	Name  string
+	Brief string
+	Text  string
+}
+
+// HelpCommandGroup is a set of subcommands of the same category. The
+// commands without a category have an empty Category.
+type HelpCommandGroup struct {
+	Category string
+	Commands []HelpCommand
+}
+
+// HelpData returns the data used to render the help text of the command.
+func (cmd *Cmd) HelpData() *HelpData {
+	data := HelpData{
+		Path:       cmd.path(),
+		Name:       cmd.Name,
+		Brief:      commandBrief(cmd),
+		Long:       cmd.Long,
+		Usage:      helpUsage(cmd),
+		Deprecated: cmd.Deprecated != nil,
+		Examples:   cmd.Examples,
+		Width:      helpWidth(cmd),
+	}
+
+	for _, op := range cmd.operands {
+		data.Operands = append(data.Operands, HelpOperand{Name: op.name, Modifier: op.modifier})
+	}
+
+	headers := make([]string, 0)
+	for _, group := range optionGroups(cmd) {
+		helpGroup := HelpOptionGroup{Name: group.name}
+
+		for _, entry := range group.entries {
+			option := HelpOption{
+				Header:      entry.helpHeader(),
+				Description: entry.helpExplain(),
+				Default:     entry.val.defaultAsString(),
+				Deprecated:  entry.Deprecated != nil,
+			}
+
+			if entry.short != 0 {
+				option.Short = "-" + string(entry.short)
+			}
+
+			if entry.long != "" {
+				option.Long = "--" + entry.long
+			}
+
+			headers = append(headers, option.Header)
+			helpGroup.Options = append(helpGroup.Options, option)
+		}
+
+		data.Options = append(data.Options, helpGroup)
+	}
+
+	data.OptionColumn = helpColumn(headers, helpMinColumn, data.Width)
+
+	headers = headers[:0]
+	for _, group := range commandGroups(cmd) {
+		helpGroup := HelpCommandGroup{Category: group.category}
+
+		for _, c := range group.commands {
+			command := HelpCommand{
+				Name:       c.Name,
+				Aliases:    c.Aliases,
+				Header:     commandHeader(c),
+				Brief:      commandBrief(c),
+				Deprecated: c.Deprecated != nil,
+			}
+
+			headers = append(headers, command.Header)
+			helpGroup.Commands = append(helpGroup.Commands, command)
+		}
+
+		data.Commands = append(data.Commands, helpGroup)
+	}
+
+	data.CommandColumn = helpColumn(headers, 0, data.Width)
+
+	headers = headers[:0]
+	for _, topic := range cmd.topics {
+		headers = append(headers, topic.name)
+		data.Topics = append(data.Topics, HelpTopic{Name: topic.name, Brief: topic.brief, Text: topic.text})
+	}
+
+	data.TopicColumn = helpColumn(headers, 0, data.Width)
+
+	return &data
+}
+
+// the main help template; just renders every section in order
+const helpMainTemplate = `{{template "brief" .}}{{template "usage" .}}{{template "long" .}}{{template "options" .}}{{template "commands" .}}{{template "topics" .}}{{template "examples" .}}`
+
+// the sections of the default help template
+var helpSections = map[string]string{
+	"brief": `{{.Path}}{{if .Brief}} - {{.Brief}}{{end}}
+`,
+
+	"usage": `{{if .Usage}}
+USAGE: {{.Usage}}
+{{end}}`,
+
+	"long": `{{if .Long}}
+{{wrap .Width .Long}}
+{{end}}`,
+
+	"options": `{{range .Options}}
+{{if .Name}}{{.Name}}{{else}}Options{{end}}:
+{{range .Options}}{{item .Header $.OptionColumn 2 .Description $.Width}}{{end}}{{end}}`,
+
+	"commands": `{{range .Commands}}
+{{if .Category}}{{.Category}}{{else}}Commands{{end}}:
+{{range .Commands}}{{item .Header $.CommandColumn 3 .Brief $.Width}}{{end}}{{end}}`,
+
+	"topics": `{{if .Topics}}
+Help Topics:
+{{range .Topics}}{{item .Name $.TopicColumn 3 .Brief $.Width}}{{end}}{{end}}`,
+
+	"examples": `{{if .Examples}}
+Examples:
+{{range .Examples}}{{if .Description}}{{indent 2 (wrap (sub $.Width 2) .Description)}}
+{{end}}    {{.Command}}
+{{end}}{{end}}`,
+}
+
+// functions available to the help templates
+var helpFuncs = template.FuncMap{
+	"wrap": func(width int, text string) string {
+		return strings.Join(wrapText(text, width), "\n")
+	},
+	"item": helpItem,
+	"indent": func(n int, text string) string {
+		prefix := strings.Repeat(" ", n)
+		return prefix + strings.Replace(text, "\n", "\n"+prefix, -1)
+	},
+	"sub": func(a, b int) int {
+		return a - b
+	},
+	"pad":  padRight,
+	"join": strings.Join,
+}
+
+// the template used by the automatic help
+var defaultHelpTemplate = template.Must(newHelpTemplate(nil))
+
+func newHelpTemplate(overrides map[string]string) (*template.Template, error) {
+	main := helpMainTemplate
+	if text, ok := overrides["help"]; ok {
+		main = text
+	}
+
+	tmpl, err := template.New("help").Funcs(helpFuncs).Parse(main)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make(map[string]string)
+	for name, text := range helpSections {
+		sections[name] = text
+	}
+
+	for name, text := range overrides {
+		if name != "help" {
+			sections[name] = text
+		}
+	}
+
+	for name, text := range sections {
+		if _, err := tmpl.New(name).Parse(text); err != nil {
+			return nil, err
+		}
+	}
+
+	return tmpl, nil
+}
+
+func renderHelp(tmpl *template.Template, cmd *Cmd, writer io.Writer) {
+	if err := tmpl.Execute(writer, cmd.HelpData()); err != nil {
+		fmt.Fprintf(writer, "error rendering help text: %v\n", err)
+	}
+}
+
+// TemplateHelp returns a help callback (to be used in Options.OnHelp) that
+// renders the help text with text/template, using a HelpData value as data.
+//
+// The default template is split in the sections 'brief', 'usage', 'long',
+// 'options', 'commands', 'topics' and 'examples', rendered in this order by the main
+// template, named 'help'. Each entry of overrides replaces the section (or the main
+// template) with the same name, so you can change only the parts you need;
+// a nil map reproduces the automatic help text. Entries with other names
+// are added as new templates, that can be used by the overridden ones.
+//
+// Besides the standard functions, the templates can use 'wrap' (wraps
+// a text to a width), 'item' (formats an item of the options or commands
+// list), 'pad' (pads a text to a width), 'indent' (indents every line of
+// a text), 'sub' (subtracts two integers) and 'join' (strings.Join).
+func TemplateHelp(overrides map[string]string) (HelpCallback, error) {
+	tmpl, err := newHelpTemplate(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(cmd *Cmd, writer io.Writer) {
+		renderHelp(tmpl, cmd, writer)
+	}, nil
+}
@@ -0,0 +1,165 @@
+package libcmd
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// the number of terminal cells needed to display the text.
+// Combining marks take no space, while wide (east asian) characters
+// take two cells
+func displayWidth(s string) int {
+	width := 0
+
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+			continue
+		case isWideRune(r):
+			width += 2
+		default:
+			width++
+		}
+	}
+
+	return width
+}
+
+// checks if the rune is in one of the main blocks of
+// wide characters (CJK, hangul, fullwidth forms and emojis)
+func isWideRune(r rune) bool {
+	return r >= 0x1100 && (r <= 0x115f ||
+		(r >= 0x2e80 && r <= 0xa4cf && r != 0x303f) ||
+		(r >= 0xac00 && r <= 0xd7a3) ||
+		(r >= 0xf900 && r <= 0xfaff) ||
+		(r >= 0xfe30 && r <= 0xfe4f) ||
+		(r >= 0xff00 && r <= 0xff60) ||
+		(r >= 0xffe0 && r <= 0xffe6) ||
+		(r >= 0x1f300 && r <= 0x1f64f) ||
+		(r >= 0x1f900 && r <= 0x1f9ff) ||
+		(r >= 0x20000 && r <= 0x3fffd))
+}
+
+// pads the text with spaces until it fills the specified width
+func padRight(s string, width int) string {
+	if n := width - displayWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+
+	return s
+}
+
+// breaks the text in lines that fit the specified width.
+// The existing line breaks and the indentation of each line are kept;
+// words larger than the width are never broken
+func wrapText(text string, width int) []string {
+	lines := make([]string, 0)
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+		indent := line[:len(line)-len(trimmed)]
+		words := strings.Fields(trimmed)
+
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+
+		current := indent + words[0]
+		for _, word := range words[1:] {
+			if displayWidth(current)+1+displayWidth(word) > width {
+				lines = append(lines, current)
+				current = indent + word
+			} else {
+				current += " " + word
+			}
+		}
+
+		lines = append(lines, current)
+	}
+
+	return lines
+}
+
+// the edit (Levenshtein) distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// finds the names similar to the specified one, from the most to the
+// least similar. Each candidate is a set of names (e. g. a command and
+// it's aliases), from which only the most similar name is used
+func similarNames(name string, candidates [][]string) []string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+
+	found := make([]suggestion, 0)
+
+	for _, names := range candidates {
+		var best *suggestion
+
+		for _, candidate := range names {
+			distance := editDistance(name, candidate)
+			if distance > 2 && !strings.HasPrefix(candidate, name) {
+				continue
+			}
+
+			if best == nil || distance < best.distance {
+				best = &suggestion{name: candidate, distance: distance}
+			}
+		}
+
+		if best != nil {
+			found = append(found, *best)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].distance != found[j].distance {
+			return found[i].distance < found[j].distance
+		}
+
+		return found[i].name < found[j].name
+	})
+
+	names := make([]string, 0, len(found))
+	for _, s := range found {
+		names = append(names, s.name)
+	}
+
+	return names
+}
@@ -0,0 +1,66 @@
+package libcmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// a named piece of documentation, that is not tied to a command
+type helpTopic struct {
+	name  string
+	brief string
+	text  string
+}
+
+// HelpTopic registers a help topic, i. e. a piece of documentation that is
+// not tied to a command (like the syntax of a filter or a list of exit codes).
+// The topics are listed on a 'Help Topics' section of the app help text, and
+// their text is shown with 'app help <name>'.
+//
+// Since topics are shown by the 'help' command, you should also set the
+// Options.HelpCommand option. Registering a topic with an existing name
+// replaces the previous one.
+func (app *App) HelpTopic(name, brief, text string) {
+	if name == "" {
+		return
+	}
+
+	topic := helpTopic{name: name, brief: brief, text: text}
+
+	for i := range app.topics {
+		if app.topics[i].name == name {
+			app.topics[i] = topic
+			return
+		}
+	}
+
+	app.topics = append(app.topics, topic)
+	sort.Slice(app.topics, func(i, j int) bool {
+		return app.topics[i].name < app.topics[j].name
+	})
+}
+
+// find a help topic by name
+func (cmd *Cmd) findTopic(name string) *helpTopic {
+	for i := range cmd.topics {
+		if cmd.topics[i].name == name {
+			return &cmd.topics[i]
+		}
+	}
+
+	return nil
+}
+
+func (cmd *Cmd) printTopic(topic *helpTopic, writer io.Writer) {
+	if topic.brief != "" {
+		fmt.Fprintf(writer, "%s - %s\n", topic.name, topic.brief)
+	} else {
+		fmt.Fprintln(writer, topic.name)
+	}
+
+	if topic.text != "" {
+		fmt.Fprintf(writer, "\n%s\n", strings.Join(wrapText(topic.text, helpWidth(cmd)), "\n"))
+	}
+}
@@ -97,6 +97,30 @@ func (v *variant) useDefault() error {
 	return nil
 }
 
+// returns a function that restores the current value
+// of the variant (and whether it was set)
+func (v *variant) snapshot() func() {
+	isSet := v.isSet
+
+	if v.refValue.Type().Implements(customArgType) {
+		ca, _ := v.refValue.Interface().(CustomArg)
+		value := ca.Get()
+
+		return func() {
+			ca.Set(value) //nolint: errcheck
+			v.isSet = isSet
+		}
+	}
+
+	value := reflect.New(v.refValue.Type()).Elem()
+	value.Set(v.refValue)
+
+	return func() {
+		v.refValue.Set(value)
+		v.isSet = isSet
+	}
+}
+
 func (v *variant) defaultAsString() string {
 	zero := reflect.Zero(v.refValue.Type())
 
@@ -0,0 +1,49 @@
+package libcmd
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+// PrintVersion prints the version text of the app to the specified writer.
+// The text contains the app name and Options.Version and, when the option
+// VersionBuildInfo is set, the module version and the VCS information
+// embedded by the Go toolchain in the executable.
+func (app *App) PrintVersion(writer io.Writer) {
+	app.printVersion(writer)
+}
+
+func (cmd *Cmd) printVersion(writer io.Writer) {
+	options := cmd.EffectiveOptions()
+	version := options.Version
+	details := make([]string, 0)
+
+	if options.VersionBuildInfo {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			if version == "" && info.Main.Version != "(devel)" {
+				version = info.Main.Version
+			}
+
+			details = append(details, fmt.Sprintf("module: %s %s", info.Main.Path, info.Main.Version))
+			details = append(details, vcsDetails(info)...)
+		}
+	}
+
+	if version == "" {
+		version = "unknown"
+	}
+
+	fmt.Fprintf(writer, "%s version %s\n", cmd.Name, version)
+	for _, line := range details {
+		fmt.Fprintln(writer, line)
+	}
+}
+
+func (cmd *Cmd) setupVersionCommand() {
+	c := cmd.Command("version", "Shows the version information.", nil)
+	c.Run(func(*Cmd) error {
+		cmd.printVersion(cmd.helpOutput())
+		return nil
+	})
+}
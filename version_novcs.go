@@ -0,0 +1,12 @@
+//go:build !go1.18
+// +build !go1.18
+
+package libcmd
+
+import "runtime/debug"
+
+// the version control information is only stamped since Go 1.18,
+// so only the module version is reported
+func vcsDetails(info *debug.BuildInfo) []string {
+	return nil
+}
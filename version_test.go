@@ -0,0 +1,55 @@
+package libcmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ibraimgm/libcmd"
+)
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		cmd      []string
+		version  string
+		expected string
+	}{
+		{cmd: []string{"--version"}, version: "1.2.3", expected: "app version 1.2.3\n"},
+		{cmd: []string{"version"}, version: "1.2.3", expected: "app version 1.2.3\n"},
+		{cmd: []string{"--version"}, expected: "app version unknown\n"},
+		{cmd: []string{"--version", "run"}, version: "1.0", expected: "app version 1.0\n"},
+		{cmd: []string{"-h"}, version: "1.0", expected: "app - some brief description\n\nUSAGE: app [OPTIONS...] COMMAND\n\nOptions:\n  --version                 Show the version information.\n  -h, --help                Show this help message.\n\nCommands:\n  run       Runs the app.\n  version   Shows the version information.\n"},
+	}
+
+	for i, test := range tests {
+		var b strings.Builder
+		app := libcmd.NewApp("app", "some brief description")
+		app.Options.HelpOutput = &b
+		app.Options.Version = test.version
+		app.Options.VersionFlag = true
+		app.Options.VersionCommand = true
+
+		app.CommandRun("run", "Runs the app.", func(*libcmd.Cmd) error {
+			t.Errorf("Case %d, the command should not run", i)
+			return nil
+		})
+
+		if err := app.ParseArgs(test.cmd); err != nil {
+			t.Errorf("Case %d, error running parser: %v", i, err)
+			continue
+		}
+
+		compareValue(t, i, test.expected, b.String())
+	}
+}
+
+func TestVersionBuildInfo(t *testing.T) {
+	var b strings.Builder
+	app := libcmd.NewApp("app", "")
+	app.Options.VersionBuildInfo = true
+	app.Options.Version = "2.0"
+	app.PrintVersion(&b)
+
+	if !strings.HasPrefix(b.String(), "app version 2.0\nmodule: ") {
+		t.Errorf("The version text should contain the build information, got:\n%s", b.String())
+	}
+}
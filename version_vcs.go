@@ -0,0 +1,38 @@
+//go:build go1.18
+// +build go1.18
+
+package libcmd
+
+import "runtime/debug"
+
+// the version control information, as stamped by 'go build'
+func vcsDetails(info *debug.BuildInfo) []string {
+	var revision, time string
+	var modified bool
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.time":
+			time = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+
+	if revision == "" {
+		return nil
+	}
+
+	if modified {
+		revision += " (dirty)"
+	}
+
+	details := []string{"revision: " + revision}
+	if time != "" {
+		details = append(details, "date: "+time)
+	}
+
+	return details
+}